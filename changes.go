@@ -0,0 +1,88 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+// Change is a value of key before and after changing.
+type Change struct {
+	Old string
+	New string
+}
+
+// Sources of config values.
+const (
+	SourceEnv     = "env"
+	SourceConsul  = "consul"
+	SourceFile    = "file"
+	SourceDefault = "default"
+)
+
+// ChangeSet is a difference between two configs.
+type ChangeSet struct {
+	// Conf is the whole current config.
+	Conf    map[string]string
+	Added   map[string]string
+	Changed map[string]Change
+	Removed map[string]string
+
+	// Sources are the sources of keys of Conf, if watcher reports them.
+	Sources map[string]string
+}
+
+// NewChangeSet returns changes from last to current config.
+func NewChangeSet(current, last map[string]string) ChangeSet {
+	cs := ChangeSet{
+		Conf:    current,
+		Added:   make(map[string]string),
+		Changed: make(map[string]Change),
+		Removed: make(map[string]string),
+	}
+
+	for k, v := range current {
+		old, ok := last[k]
+		if !ok {
+			cs.Added[k] = v
+
+			continue
+		}
+
+		if old != v {
+			cs.Changed[k] = Change{Old: old, New: v}
+		}
+	}
+
+	for k, v := range last {
+		if _, ok := current[k]; !ok {
+			cs.Removed[k] = v
+		}
+	}
+
+	return cs
+}
+
+// Empty reports whether nothing has been changed.
+func (cs ChangeSet) Empty() bool {
+	return len(cs.Added) == 0 && len(cs.Changed) == 0 && len(cs.Removed) == 0
+}
+
+// ApplyChangesFunc applies a change set of config.
+type ApplyChangesFunc func(ChangeSet)
+
+// ChangesFunc adapts ApplyConfigFunc to ApplyChangesFunc. The whole config
+// is passed as conf and the removed keys are passed as reset.
+func (f ApplyConfigFunc) ChangesFunc() ApplyChangesFunc {
+	return func(cs ChangeSet) {
+		f(cs.Conf, cs.Removed)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/imega/daemon"
+)
+
+// Store keeps application keys up to date. It is fed by any ConfigReader
+// through its WatcherConfigFunc.
+//
+// # Example
+//
+// limits := config.New("my-daemon", "limits", "max-items", "timeout")
+// cr := consul.Watch(log, limits.WatcherConfigFunc)
+// ...
+// maxItems := limits.GetInt("max-items", 100).
+type Store struct {
+	prefix  string
+	mainKey string
+
+	mx     sync.RWMutex
+	values map[string]string
+	subs   map[string]map[int]func(daemon.Change)
+	nextID int
+
+	daemon.WatcherConfigFunc
+}
+
+// New returns a Store of keys under prefix/mainKey.
+func New(prefix, mainKey string, keys ...string) *Store {
+	s := &Store{
+		prefix:  prefix,
+		mainKey: mainKey,
+		values:  make(map[string]string),
+		subs:    make(map[string]map[int]func(daemon.Change)),
+	}
+
+	s.WatcherConfigFunc = func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:       prefix,
+			MainKey:      mainKey,
+			Keys:         keys,
+			ApplyChanges: s.apply,
+		}
+	}
+
+	return s
+}
+
+// Lookup returns the value of key and reports whether it is set.
+func (s *Store) Lookup(key string) (string, bool) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	v, ok := s.values[s.fullKey(key)]
+
+	return v, ok
+}
+
+// GetString returns the value of key or def if key is not set.
+func (s *Store) GetString(key, def string) string {
+	if v, ok := s.Lookup(key); ok {
+		return v
+	}
+
+	return def
+}
+
+// GetInt returns the value of key or def if key is not set or is not a number.
+func (s *Store) GetInt(key string, def int) int {
+	v, ok := s.Lookup(key)
+	if !ok {
+		return def
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+
+	return i
+}
+
+// GetDuration returns the value of key or def if key is not set
+// or is not a duration.
+func (s *Store) GetDuration(key string, def time.Duration) time.Duration {
+	v, ok := s.Lookup(key)
+	if !ok {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+
+	return d
+}
+
+// GetBool returns the value of key or def if key is not set or is not a bool.
+func (s *Store) GetBool(key string, def bool) bool {
+	v, ok := s.Lookup(key)
+	if !ok {
+		return def
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+
+	return b
+}
+
+// OnChange subscribes fn to changes of key. New is empty if key is removed.
+// It returns a function to unsubscribe.
+func (s *Store) OnChange(key string, fn func(daemon.Change)) func() {
+	full := s.fullKey(key)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	id := s.nextID
+	s.nextID++
+
+	if s.subs[full] == nil {
+		s.subs[full] = make(map[int]func(daemon.Change))
+	}
+
+	s.subs[full][id] = fn
+
+	return func() {
+		s.mx.Lock()
+		defer s.mx.Unlock()
+
+		delete(s.subs[full], id)
+	}
+}
+
+func (s *Store) fullKey(key string) string {
+	return s.prefix + "/" + s.mainKey + "/" + key
+}
+
+type notification struct {
+	fn     func(daemon.Change)
+	change daemon.Change
+}
+
+func (s *Store) apply(cs daemon.ChangeSet) {
+	values := make(map[string]string, len(cs.Conf))
+	for k, v := range cs.Conf {
+		values[k] = v
+	}
+
+	s.mx.Lock()
+
+	s.values = values
+	queue := []notification{}
+
+	notify := func(key string, change daemon.Change) {
+		for _, fn := range s.subs[key] {
+			queue = append(queue, notification{fn: fn, change: change})
+		}
+	}
+
+	for k, v := range cs.Added {
+		notify(k, daemon.Change{New: v})
+	}
+
+	for k, c := range cs.Changed {
+		notify(k, c)
+	}
+
+	for k, v := range cs.Removed {
+		notify(k, daemon.Change{Old: v})
+	}
+
+	s.mx.Unlock()
+
+	for _, n := range queue {
+		n.fn(n.change)
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imega/daemon"
+)
+
+func TestStore_Getters(t *testing.T) {
+	s := New("my-daemon", "limits")
+
+	s.WatcherConfigFunc().ApplyChanges(daemon.NewChangeSet(map[string]string{
+		"my-daemon/limits/name":    "test",
+		"my-daemon/limits/items":   "10",
+		"my-daemon/limits/timeout": "3s",
+		"my-daemon/limits/enabled": "true",
+		"my-daemon/limits/broken":  "ten",
+	}, nil))
+
+	if got := s.GetString("name", ""); got != "test" {
+		t.Errorf("GetString() = %s, want test", got)
+	}
+
+	if got := s.GetString("unknown", "def"); got != "def" {
+		t.Errorf("GetString() = %s, want def", got)
+	}
+
+	if got := s.GetInt("items", 0); got != 10 {
+		t.Errorf("GetInt() = %d, want 10", got)
+	}
+
+	if got := s.GetInt("broken", 5); got != 5 {
+		t.Errorf("GetInt() = %d, want 5", got)
+	}
+
+	if got := s.GetDuration("timeout", 0); got != 3*time.Second {
+		t.Errorf("GetDuration() = %s, want 3s", got)
+	}
+
+	if got := s.GetBool("enabled", false); !got {
+		t.Errorf("GetBool() = %v, want true", got)
+	}
+}
+
+func TestStore_OnChange(t *testing.T) {
+	s := New("my-daemon", "limits")
+	apply := s.WatcherConfigFunc().ApplyChanges
+
+	var changes []daemon.Change
+
+	unsubscribe := s.OnChange("items", func(c daemon.Change) {
+		changes = append(changes, c)
+	})
+
+	first := map[string]string{"my-daemon/limits/items": "10"}
+	second := map[string]string{"my-daemon/limits/items": "20"}
+
+	apply(daemon.NewChangeSet(first, nil))
+	apply(daemon.NewChangeSet(second, first))
+	apply(daemon.NewChangeSet(nil, second))
+
+	unsubscribe()
+	apply(daemon.NewChangeSet(first, nil))
+
+	want := []daemon.Change{{New: "10"}, {Old: "10", New: "20"}, {Old: "20"}}
+	if len(changes) != len(want) {
+		t.Fatalf("OnChange() got %d changes, want %d", len(changes), len(want))
+	}
+
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("OnChange() change %d = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	s := New("my-daemon", "limits")
+	apply := s.WatcherConfigFunc().ApplyChanges
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			cancel := s.OnChange("items", func(daemon.Change) {})
+			s.GetInt("items", 0)
+			cancel()
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			apply(daemon.NewChangeSet(map[string]string{"my-daemon/limits/items": "1"}, nil))
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,202 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coalesce
+
+import (
+	"sync"
+	"time"
+
+	"github.com/imega/daemon"
+)
+
+// Coalescer batches config changes of WatcherConfigs which share the same
+// Group and passes them to the ApplyChangesFunc in a single call.
+// The first call of group is always made, even with an empty config,
+// so connectors start with their defaults. The next calls are
+// suppressed if the merged config has not changed.
+//
+// Every change restarts the window of its group, so related keys changed
+// one after another (a host and a password, for example) are applied
+// together once the window has passed without further changes.
+type Coalescer struct {
+	window time.Duration
+
+	mx      sync.Mutex
+	order   []*group
+	groups  map[string]*group
+	members map[string]*group
+}
+
+type group struct {
+	apply daemon.ApplyChangesFunc
+
+	applyMx sync.Mutex
+	snaps   map[string]snapshot
+	last    map[string]string
+	applied bool
+	dirty   bool
+	timer   *time.Timer
+}
+
+// New returns a Coalescer. Changes are applied immediately
+// if window is not positive.
+func New(window time.Duration) *Coalescer {
+	return &Coalescer{
+		window:  window,
+		groups:  make(map[string]*group),
+		members: make(map[string]*group),
+	}
+}
+
+// Key returns the key of a member of group.
+func Key(wConf daemon.WatcherConfig) string {
+	return wConf.Prefix + "/" + wConf.MainKey
+}
+
+// Add registers the WatcherConfig and returns its key. WatcherConfigs
+// without Group make up a group of their own.
+func (c *Coalescer) Add(wConf daemon.WatcherConfig) string {
+	key := Key(wConf)
+
+	name := wConf.Group
+	if name == "" {
+		name = key
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	g, ok := c.groups[name]
+	if !ok {
+		g = &group{
+			apply: wConf.ChangesFunc(),
+			snaps: make(map[string]snapshot),
+		}
+		c.groups[name] = g
+		c.order = append(c.order, g)
+	}
+
+	c.members[key] = g
+
+	return key
+}
+
+type snapshot struct {
+	conf    map[string]string
+	sources map[string]string
+}
+
+// Stage stores the config of member and the sources of its keys
+// without applying it. Sources may be nil.
+func (c *Coalescer) Stage(key string, conf, sources map[string]string) {
+	c.stage(key, conf, sources)
+}
+
+// Update stores the config of member and the sources of its keys
+// and schedules applying of its group. Sources may be nil.
+func (c *Coalescer) Update(key string, conf, sources map[string]string) {
+	g := c.stage(key, conf, sources)
+	if g == nil {
+		return
+	}
+
+	if c.window <= 0 {
+		c.flush(g)
+
+		return
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+
+	g.timer = time.AfterFunc(c.window, func() { c.flush(g) })
+}
+
+// Flush applies all pending changes immediately.
+func (c *Coalescer) Flush() {
+	c.mx.Lock()
+	groups := append([]*group(nil), c.order...)
+	c.mx.Unlock()
+
+	for _, g := range groups {
+		c.flush(g)
+	}
+}
+
+func (c *Coalescer) stage(key string, conf, sources map[string]string) *group {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	g, ok := c.members[key]
+	if !ok {
+		return nil
+	}
+
+	g.snaps[key] = snapshot{conf: conf, sources: sources}
+	g.dirty = true
+
+	return g
+}
+
+func (c *Coalescer) flush(g *group) {
+	g.applyMx.Lock()
+	defer g.applyMx.Unlock()
+
+	c.mx.Lock()
+
+	if g.timer != nil {
+		g.timer.Stop()
+		g.timer = nil
+	}
+
+	if !g.dirty {
+		c.mx.Unlock()
+
+		return
+	}
+
+	conf := make(map[string]string)
+	sources := make(map[string]string)
+
+	for _, snap := range g.snaps {
+		for k, v := range snap.conf {
+			conf[k] = v
+		}
+
+		for k, v := range snap.sources {
+			sources[k] = v
+		}
+	}
+
+	g.dirty = false
+	changes := daemon.NewChangeSet(conf, g.last)
+	changes.Sources = sources
+	g.last = conf
+
+	first := !g.applied
+	g.applied = true
+
+	c.mx.Unlock()
+
+	if changes.Empty() && !first {
+		return
+	}
+
+	g.apply(changes)
+}
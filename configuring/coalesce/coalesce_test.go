@@ -0,0 +1,202 @@
+package coalesce
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imega/daemon"
+)
+
+type applied struct {
+	mx    sync.Mutex
+	calls []map[string]string
+	reset []map[string]string
+}
+
+func (a *applied) apply(conf, reset map[string]string) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	a.calls = append(a.calls, conf)
+	a.reset = append(a.reset, reset)
+}
+
+func (a *applied) count() int {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	return len(a.calls)
+}
+
+func members(a *applied) (daemon.WatcherConfig, daemon.WatcherConfig) {
+	host := daemon.WatcherConfig{
+		Prefix:    "instance",
+		MainKey:   "mysql",
+		ApplyFunc: a.apply,
+		Group:     "mysql",
+	}
+	client := daemon.WatcherConfig{
+		Prefix:    "client",
+		MainKey:   "mysql",
+		ApplyFunc: a.apply,
+		Group:     "mysql",
+	}
+
+	return host, client
+}
+
+func TestCoalescer_FlushAppliesGroupOnce(t *testing.T) {
+	a := &applied{}
+	host, client := members(a)
+
+	c := New(0)
+	hKey := c.Add(host)
+	cKey := c.Add(client)
+
+	c.Stage(hKey, map[string]string{"instance/mysql/host": "db:3306"}, nil)
+	c.Stage(cKey, map[string]string{"client/mysql/password": "secret"}, nil)
+	c.Flush()
+
+	if a.count() != 1 {
+		t.Fatalf("Flush() applied %d times, want 1", a.count())
+	}
+
+	want := map[string]string{
+		"instance/mysql/host":   "db:3306",
+		"client/mysql/password": "secret",
+	}
+	if !reflect.DeepEqual(a.calls[0], want) {
+		t.Errorf("Flush() conf = %v, want %v", a.calls[0], want)
+	}
+
+	c.Flush()
+
+	if a.count() != 1 {
+		t.Errorf("Flush() without changes must not apply")
+	}
+}
+
+func TestCoalescer_UpdateWithinWindow(t *testing.T) {
+	a := &applied{}
+	host, client := members(a)
+
+	c := New(50 * time.Millisecond)
+	hKey := c.Add(host)
+	cKey := c.Add(client)
+
+	c.Update(hKey, map[string]string{"instance/mysql/host": "db:3306"}, nil)
+	c.Update(cKey, map[string]string{"client/mysql/password": "secret"}, nil)
+
+	time.Sleep(200 * time.Millisecond)
+
+	if a.count() != 1 {
+		t.Fatalf("Update() applied %d times, want 1", a.count())
+	}
+
+	c.Update(cKey, map[string]string{"client/mysql/user": "root"}, nil)
+
+	time.Sleep(200 * time.Millisecond)
+
+	if a.count() != 2 {
+		t.Fatalf("Update() applied %d times, want 2", a.count())
+	}
+
+	wantReset := map[string]string{"client/mysql/password": "secret"}
+	if !reflect.DeepEqual(a.reset[1], wantReset) {
+		t.Errorf("Update() reset = %v, want %v", a.reset[1], wantReset)
+	}
+}
+
+func TestCoalescer_WithoutGroup(t *testing.T) {
+	a := &applied{}
+	host, client := members(a)
+	host.Group, client.Group = "", ""
+
+	c := New(0)
+	hKey := c.Add(host)
+	cKey := c.Add(client)
+
+	c.Update(hKey, map[string]string{"instance/mysql/host": "db:3306"}, nil)
+	c.Update(cKey, map[string]string{"client/mysql/password": "secret"}, nil)
+
+	if a.count() != 2 {
+		t.Errorf("Update() applied %d times, want 2", a.count())
+	}
+}
+
+func TestCoalescer_SuppressesUnchanged(t *testing.T) {
+	var changes []daemon.ChangeSet
+
+	c := New(0)
+	key := c.Add(daemon.WatcherConfig{
+		Prefix:  "client",
+		MainKey: "mysql",
+		ApplyChanges: func(cs daemon.ChangeSet) {
+			changes = append(changes, cs)
+		},
+	})
+
+	c.Update(key, map[string]string{"client/mysql/user": "root"}, nil)
+	c.Update(key, map[string]string{"client/mysql/user": "root"}, nil)
+	c.Update(key, map[string]string{}, nil)
+	c.Update(key, map[string]string{}, nil)
+
+	if len(changes) != 2 {
+		t.Fatalf("Update() applied %d times, want 2", len(changes))
+	}
+
+	if changes[0].Added["client/mysql/user"] != "root" {
+		t.Errorf("Update() added = %v", changes[0].Added)
+	}
+
+	if changes[1].Removed["client/mysql/user"] != "root" {
+		t.Errorf("Update() removed = %v", changes[1].Removed)
+	}
+}
+
+func TestCoalescer_AppliesFirstEmpty(t *testing.T) {
+	a := &applied{}
+
+	c := New(0)
+	key := c.Add(daemon.WatcherConfig{Prefix: "my-daemon", MainKey: "http", ApplyFunc: a.apply})
+
+	c.Update(key, map[string]string{}, nil)
+	c.Update(key, map[string]string{}, nil)
+
+	if a.count() != 1 {
+		t.Fatalf("Update() applied %d times, want 1", a.count())
+	}
+
+	if len(a.calls[0]) != 0 {
+		t.Errorf("Update() conf = %v, want empty", a.calls[0])
+	}
+}
+
+func TestCoalescer_Changed(t *testing.T) {
+	var changes daemon.ChangeSet
+
+	c := New(0)
+	key := c.Add(daemon.WatcherConfig{
+		Prefix:  "client",
+		MainKey: "mysql",
+		ApplyChanges: func(cs daemon.ChangeSet) {
+			changes = cs
+		},
+	})
+
+	c.Update(key, map[string]string{"client/mysql/user": "root"}, nil)
+	c.Update(key, map[string]string{"client/mysql/user": "admin"}, nil)
+
+	want := map[string]daemon.Change{
+		"client/mysql/user": {Old: "root", New: "admin"},
+	}
+	if !reflect.DeepEqual(changes.Changed, want) {
+		t.Errorf("Update() changed = %v, want %v", changes.Changed, want)
+	}
+
+	if len(changes.Added) != 0 || len(changes.Removed) != 0 {
+		t.Errorf("Update() = %+v, want only changed keys", changes)
+	}
+}
@@ -17,119 +17,129 @@ package consul
 import (
 	"io"
 	"log"
-	"os"
+	"strings"
 
 	"github.com/hashicorp/go-hclog"
-	"github.com/sirupsen/logrus"
+	"github.com/imega/daemon/logging"
 )
 
-func newConsulLogger(log logrus.FieldLogger) hclog.Logger {
-	l := &logger{log: log}
-
-	l.hlog = l
+func newConsulLogger(log logging.Logger) hclog.Logger {
+	if log == nil {
+		log = logging.GetNoopLog()
+	}
 
-	return l
+	return &logger{log: log}
 }
 
 type logger struct {
-	log  logrus.FieldLogger
-	hlog hclog.Logger
+	log  logging.Logger
+	name string
+	args []interface{}
 }
 
 func (l *logger) Log(level hclog.Level, msg string, args ...interface{}) {
-	if e, ok := l.log.(*logrus.Entry); ok {
-		e.Log(logrus.Level(level), msg, args)
+	switch level {
+	case hclog.Trace:
+		l.Trace(msg, args...)
+	case hclog.Debug:
+		l.Debug(msg, args...)
+	case hclog.Warn:
+		l.Warn(msg, args...)
+	case hclog.Error:
+		l.Error(msg, args...)
+	default:
+		l.Info(msg, args...)
 	}
 }
 
 func (l *logger) Trace(msg string, args ...interface{}) {
-	if e, ok := l.log.(*logrus.Entry); ok {
-		e.Trace(msg, args)
-	}
+	l.log.Debugw(msg, args...)
 }
 
 func (l *logger) Debug(msg string, args ...interface{}) {
-	l.log.Debug(msg, args)
+	l.log.Debugw(msg, args...)
 }
 
 func (l *logger) Info(msg string, args ...interface{}) {
-	l.log.Info(msg, args)
+	l.log.Infow(msg, args...)
 }
 
 func (l *logger) Warn(msg string, args ...interface{}) {
-	l.log.Warn(msg, args)
+	l.log.Warnw(msg, args...)
 }
 
 func (l *logger) Error(msg string, args ...interface{}) {
-	l.log.Error(msg, args)
+	l.log.Errorw(msg, args...)
 }
 
 func (l *logger) IsTrace() bool {
-	return l.log.(*logrus.Entry).Logger.Level <= logrus.DebugLevel
+	return logging.Enabled(l.log, logging.LevelDebug)
 }
 
 func (l *logger) IsDebug() bool {
-	if e, ok := l.log.(*logrus.Entry); ok {
-		return e.Level <= logrus.DebugLevel
-	}
-
-	return false
+	return logging.Enabled(l.log, logging.LevelDebug)
 }
 
 func (l *logger) IsInfo() bool {
-	if e, ok := l.log.(*logrus.Entry); ok {
-		return e.Level <= logrus.InfoLevel
-	}
-
-	return false
+	return logging.Enabled(l.log, logging.LevelInfo)
 }
 
 func (l *logger) IsWarn() bool {
-	if e, ok := l.log.(*logrus.Entry); ok {
-		return e.Level <= logrus.WarnLevel
-	}
-
-	return false
+	return logging.Enabled(l.log, logging.LevelWarn)
 }
 
 func (l *logger) IsError() bool {
-	if e, ok := l.log.(*logrus.Entry); ok {
-		return e.Level <= logrus.ErrorLevel
-	}
-
-	return false
+	return logging.Enabled(l.log, logging.LevelError)
 }
 
 func (l *logger) ImpliedArgs() []interface{} {
-	return nil
+	return l.args
 }
 
 func (l *logger) With(args ...interface{}) hclog.Logger {
-	return nil
+	return &logger{
+		log:  l.log.WithFields(logging.Fields(args...)),
+		name: l.name,
+		args: append(append([]interface{}{}, l.args...), args...),
+	}
 }
 
 func (l *logger) Name() string {
-	return ""
+	return l.name
 }
 
 func (l *logger) Named(name string) hclog.Logger {
-	return l.hlog
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	return l.ResetNamed(name)
 }
 
 func (l *logger) ResetNamed(name string) hclog.Logger {
-	return l.hlog
+	return &logger{
+		log:  l.log.WithFields(map[string]interface{}{"@module": name}),
+		name: name,
+		args: l.args,
+	}
 }
 
 func (l *logger) SetLevel(level hclog.Level) {}
 
 func (l *logger) StandardLogger(opts *hclog.StandardLoggerOptions) *log.Logger {
-	return nil
+	return log.New(l.StandardWriter(opts), "", 0)
 }
 
 func (l *logger) StandardWriter(opts *hclog.StandardLoggerOptions) io.Writer {
-	if e, ok := l.log.(*logrus.Entry); ok {
-		return e.Logger.Out
-	}
+	return &writer{log: l.log}
+}
+
+type writer struct {
+	log logging.Logger
+}
+
+func (w *writer) Write(p []byte) (n int, err error) {
+	w.log.Infof("%s", strings.TrimRight(string(p), "\n"))
 
-	return os.Stdout
+	return len(p), nil
 }
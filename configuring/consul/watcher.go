@@ -15,40 +15,132 @@
 package consul
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/api/watch"
 	"github.com/imega/daemon"
-	"github.com/sirupsen/logrus"
+	"github.com/imega/daemon/configuring/coalesce"
+	"github.com/imega/daemon/configuring/encryption"
+	"github.com/imega/daemon/configuring/instance"
+	"github.com/imega/daemon/configuring/schema"
+	"github.com/imega/daemon/configuring/secrets"
+	"github.com/imega/daemon/logging"
 )
 
 type Watcher struct {
-	log           logrus.FieldLogger
+	log           logging.Logger
 	wathFunc      []daemon.WatcherConfigFunc
+	window        time.Duration
+	secrets       *secrets.Set
+	cipher        *encryption.Cipher
+	instanceID    string
+	reject        RejectFunc
+	warnedMx      sync.Mutex
+	warned        map[string]struct{}
 	LastConfMutex sync.RWMutex
 	LastConf      map[string]map[string]string
 }
 
+const defaultWindow = 500 * time.Millisecond
+
+var errNotResolved = errors.New("key has no resolved value")
+
 // Watch .
-func Watch(log logrus.FieldLogger, f ...daemon.WatcherConfigFunc) *Watcher {
+func Watch(log logging.Logger, f ...daemon.WatcherConfigFunc) *Watcher {
+	if log == nil {
+		log = logging.GetNoopLog()
+	}
+
 	return &Watcher{
 		log:           log,
 		wathFunc:      f,
+		window:        defaultWindow,
+		secrets:       secrets.NewEmpty(),
+		instanceID:    instance.ID(),
+		warned:        make(map[string]struct{}),
 		LastConfMutex: sync.RWMutex{},
 		LastConf:      make(map[string]map[string]string),
 	}
 }
 
+// Option configures the Watcher.
+type Option func(*Watcher)
+
+// WithWindow sets the period during which changes of a group
+// are collected before they are applied. Zero disables batching.
+func WithWindow(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.window = d
+	}
+}
+
+// WithSecrets sets resolvers of references in values, no references
+// are resolved by default. Register file and env resolvers only if
+// the writers of Consul may read the files and the environment.
+func WithSecrets(s *secrets.Set) Option {
+	return func(w *Watcher) {
+		w.secrets = s
+	}
+}
+
+// WithCipher sets the cipher of encrypted values. By default the key
+// is read from encryption.KeyEnv if it is set.
+func WithCipher(c *encryption.Cipher) Option {
+	return func(w *Watcher) {
+		w.cipher = c
+	}
+}
+
+// WithInstanceID sets the id of the instance-scoped overlay,
+// prefix/main-key/@instances/<id>/... By default it is instance.ID().
+func WithInstanceID(id string) Option {
+	return func(w *Watcher) {
+		w.instanceID = id
+	}
+}
+
+// RejectFunc is called with the config which is not applied.
+type RejectFunc func(wConf daemon.WatcherConfig, conf map[string]string, err error)
+
+// WithRejectFunc sets the function called when a config is not applied,
+// e.g. Metrics.Reject of package metrics.
+func WithRejectFunc(fn RejectFunc) Option {
+	return func(w *Watcher) {
+		w.reject = fn
+	}
+}
+
+// With applies options to the Watcher. It must be called before Read.
+func (w *Watcher) With(opts ...Option) *Watcher {
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
 func (w *Watcher) Read() error {
 	hlog := newConsulLogger(w.log)
 	conf := api.DefaultConfigWithLogger(hlog)
+	batch := coalesce.New(w.window)
+
+	if w.cipher == nil {
+		c, err := encryption.FromEnv()
+		if err != nil && !errors.Is(err, encryption.ErrNoKey) {
+			return fmt.Errorf("failed to create cipher: %w", err)
+		}
+
+		w.cipher = c
+	}
 
 	for _, fn := range w.wathFunc {
 		wConf := fn()
 
-		prefixKey := wConf.Prefix + "/" + wConf.MainKey
+		prefixKey := batch.Add(wConf)
 
 		plan, err := watch.Parse(map[string]interface{}{
 			"type":   "keyprefix",
@@ -60,7 +152,7 @@ func (w *Watcher) Read() error {
 
 		plan.Logger = hlog
 
-		func(p *watch.Plan, cbFunc daemon.ApplyConfigFunc, key string) {
+		func(p *watch.Plan, key string, wConf daemon.WatcherConfig) {
 			p.HybridHandler = func(v watch.BlockingParamVal, i interface{}) {
 				conf := make(map[string]string)
 
@@ -70,23 +162,13 @@ func (w *Watcher) Read() error {
 					}
 				}
 
-				if len(conf) == 0 {
-					return
-				}
-
-				w.LastConfMutex.RLock()
-				cbFunc(conf, keys4reset(conf, w.LastConf[key]))
-				w.LastConfMutex.RUnlock()
-
-				w.LastConfMutex.Lock()
-				w.LastConf[key] = conf
-				w.LastConfMutex.Unlock()
+				w.update(batch, key, wConf, conf)
 			}
-		}(plan, wConf.ApplyFunc, prefixKey)
+		}(plan, prefixKey, wConf)
 
 		go func() {
 			if err := plan.RunWithConfig(conf.Address, conf); err != nil {
-				w.log.Error(err)
+				w.log.WithError(err).Errorf("failed to watch consul")
 			}
 		}()
 	}
@@ -94,14 +176,101 @@ func (w *Watcher) Read() error {
 	return nil
 }
 
-func keys4reset(current, last map[string]string) map[string]string {
-	reset := make(map[string]string)
+// update applies conf of key. An empty conf is skipped, so connectors
+// don't start with their defaults before the keys exist in Consul.
+func (w *Watcher) update(batch *coalesce.Coalescer, key string, wConf daemon.WatcherConfig, conf map[string]string) {
+	if len(conf) == 0 {
+		return
+	}
+
+	conf = instance.Merge(key, w.instanceID, conf)
+	w.warnUnknown(wConf, conf)
+
+	res, sources, err := w.resolve(key, conf)
+	if err != nil {
+		w.log.WithError(err).Errorf("config of %s is not applied", key)
+
+		if w.reject != nil {
+			w.reject(wConf, conf, err)
+		}
+
+		return
+	}
+
+	w.LastConfMutex.Lock()
+	w.LastConf[key] = res
+	w.LastConfMutex.Unlock()
+
+	batch.Update(key, res, sources)
+}
+
+// resolve decrypts values and resolves references of conf. A value which
+// fails keeps the last resolved one, so a transient error is not
+// applied as removing of key. If there is no last value, the update
+// is skipped.
+func (w *Watcher) resolve(key string, conf map[string]string) (map[string]string, map[string]string, error) {
+	res, err := w.cipher.Conf(conf)
+	if err != nil {
+		w.log.WithError(err).Errorf("failed to decrypt config")
+	}
+
+	sources := w.sources(res)
+
+	res, err = w.secrets.Conf(res)
+	if err != nil {
+		w.log.WithError(err).Errorf("failed to resolve secrets")
+	}
+
+	w.LastConfMutex.RLock()
+	last := w.LastConf[key]
+	w.LastConfMutex.RUnlock()
+
+	for k := range conf {
+		if _, ok := res[k]; ok {
+			continue
+		}
+
+		v, ok := last[k]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s", errNotResolved, k)
+		}
+
+		w.log.Warnf("config key %s keeps the last resolved value", k)
+
+		res[k] = v
+
+		if _, ok := sources[k]; !ok {
+			sources[k] = daemon.SourceConsul
+		}
+	}
+
+	return res, sources, nil
+}
+
+func (w *Watcher) warnUnknown(wConf daemon.WatcherConfig, conf map[string]string) {
+	w.warnedMx.Lock()
+	defer w.warnedMx.Unlock()
+
+	for _, k := range schema.Unknown(wConf, conf) {
+		if _, ok := w.warned[k]; ok {
+			continue
+		}
+
+		w.warned[k] = struct{}{}
+		w.log.Warnf("unknown config key %s", k)
+	}
+}
+
+func (w *Watcher) sources(conf map[string]string) map[string]string {
+	sources := make(map[string]string, len(conf))
+
+	for k, v := range conf {
+		sources[k] = daemon.SourceConsul
 
-	for k, v := range last {
-		if _, ok := current[k]; !ok {
-			reset[k] = v
+		if scheme, ok := w.secrets.Source(v); ok {
+			sources[k] = scheme
 		}
 	}
 
-	return reset
+	return sources
 }
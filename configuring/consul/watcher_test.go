@@ -0,0 +1,164 @@
+package consul
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/configuring/coalesce"
+	"github.com/imega/daemon/configuring/encryption"
+	"github.com/imega/daemon/configuring/secrets"
+)
+
+func TestWatcher_resolve(t *testing.T) {
+	var vaultErr error
+
+	set := secrets.NewEmpty().Register("vault", secrets.ResolverFunc(func(*url.URL) (string, error) {
+		return "secret", vaultErr
+	}))
+
+	w := Watch(nil).With(WithSecrets(set))
+
+	conf := map[string]string{
+		"my-daemon/mysql/password": "vault://secret/data/db#password",
+		"my-daemon/mysql/host":     "mysql:3306",
+		"my-daemon/mysql/user":     "file:///etc/passwd",
+	}
+
+	vaultErr = errors.New("vault is unavailable")
+
+	if _, _, err := w.resolve("my-daemon/mysql", conf); !errors.Is(err, errNotResolved) {
+		t.Fatalf("expected skipped update without last value, got %v", err)
+	}
+
+	vaultErr = nil
+
+	got, sources, err := w.resolve("my-daemon/mysql", conf)
+	if err != nil || got["my-daemon/mysql/password"] != "secret" || sources["my-daemon/mysql/password"] != "vault" {
+		t.Fatalf("unexpected conf %v, %v", got, sources)
+	}
+
+	if got["my-daemon/mysql/user"] != "file:///etc/passwd" {
+		t.Errorf("file references must not be resolved by default, got %s", got["my-daemon/mysql/user"])
+	}
+
+	w.LastConf["my-daemon/mysql"] = got
+	vaultErr = errors.New("vault is unavailable")
+	conf["my-daemon/mysql/host"] = "mysql-2:3306"
+
+	got, _, err = w.resolve("my-daemon/mysql", conf)
+	if err != nil || got["my-daemon/mysql/password"] != "secret" || got["my-daemon/mysql/host"] != "mysql-2:3306" {
+		t.Fatalf("expected the last password and the new host, got %v", got)
+	}
+}
+
+func TestWatcher_resolve_Decrypt(t *testing.T) {
+	key, err := encryption.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(key)
+
+	c, err := encryption.New(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := c.Encrypt("my-daemon/mysql/password", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := Watch(nil).With(WithCipher(c))
+
+	got, _, err := w.resolve("my-daemon/mysql", map[string]string{"my-daemon/mysql/password": encrypted})
+	if err != nil || got["my-daemon/mysql/password"] != "secret" {
+		t.Fatalf("unexpected conf %v", got)
+	}
+
+	w.LastConf["my-daemon/mysql"] = got
+
+	got, _, err = w.resolve("my-daemon/mysql", map[string]string{
+		"my-daemon/mysql/password": encrypted,
+		"my-daemon/redis/password": encrypted,
+	})
+	if err == nil {
+		t.Fatalf("expected skipped update, the value is bound to another key, got %v", got)
+	}
+
+	got, _, err = (Watch(nil).With(WithCipher(nil))).resolve("my-daemon/mysql", map[string]string{"my-daemon/mysql/password": encrypted})
+	if err == nil {
+		t.Fatalf("expected skipped update without key, got %v", got)
+	}
+}
+
+func TestWatcher_update_Reject(t *testing.T) {
+	set := secrets.NewEmpty().Register("vault", secrets.ResolverFunc(func(*url.URL) (string, error) {
+		return "", errors.New("vault is unavailable")
+	}))
+
+	var (
+		applied  int
+		rejected []string
+	)
+
+	wConf := daemon.WatcherConfig{
+		Prefix:  "my-daemon",
+		MainKey: "mysql",
+		Keys:    []string{"password"},
+		ApplyFunc: func(conf, reset map[string]string) {
+			applied++
+		},
+	}
+
+	w := Watch(nil).With(WithSecrets(set), WithRejectFunc(func(wConf daemon.WatcherConfig, conf map[string]string, err error) {
+		rejected = append(rejected, wConf.MainKey)
+	}))
+
+	batch := coalesce.New(0)
+	key := batch.Add(wConf)
+
+	w.update(batch, key, wConf, map[string]string{"my-daemon/mysql/password": "vault://secret/data/db#password"})
+
+	if applied != 0 || len(rejected) != 1 || rejected[0] != "mysql" {
+		t.Errorf("expected rejected config, applied %d, rejected %v", applied, rejected)
+	}
+
+	w.update(batch, key, wConf, map[string]string{"my-daemon/mysql/password": "secret"})
+
+	if applied != 1 || len(rejected) != 1 {
+		t.Errorf("expected applied config, applied %d, rejected %v", applied, rejected)
+	}
+}
+
+func TestWatcher_update_Empty(t *testing.T) {
+	var applied []map[string]string
+
+	wConf := daemon.WatcherConfig{
+		Prefix:  "my-daemon",
+		MainKey: "mysql",
+		Keys:    []string{"host"},
+		ApplyFunc: func(conf, reset map[string]string) {
+			applied = append(applied, conf)
+		},
+	}
+
+	w := Watch(nil)
+	batch := coalesce.New(0)
+	key := batch.Add(wConf)
+
+	w.update(batch, key, wConf, map[string]string{})
+
+	if len(applied) != 0 {
+		t.Fatalf("expected skipped empty config, got %v", applied)
+	}
+
+	w.update(batch, key, wConf, map[string]string{"my-daemon/mysql/host": "mysql:3306"})
+
+	if len(applied) != 1 || applied[0]["my-daemon/mysql/host"] != "mysql:3306" {
+		t.Errorf("unexpected applies %v", applied)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/imega/daemon/configuring/env"
+)
+
+// Prefix marks encrypted values, enc:v1:<base64(nonce|ciphertext)>.
+// The values are encrypted by AES-256-GCM with the name of key as
+// additional data, so a value can't be copied under another key.
+// The name is the full key, e.g. my-daemon/mysql/password, the values
+// of instance overlays are bound to the shared key.
+const Prefix = "enc:v1:"
+
+// KeyEnv is the name of environment variable with base64 encoded key.
+// KeyEnv_FILE may be used instead, see env.Read.
+const KeyEnv = "CONFIG_ENCRYPTION_KEY"
+
+const keySize = 32
+
+var (
+	// ErrNoKey is returned if the key is not set.
+	ErrNoKey = errors.New("encryption key is not set")
+
+	// ErrMalformed is returned if encrypted value is malformed.
+	ErrMalformed = errors.New("malformed encrypted value")
+)
+
+// Cipher encrypts and decrypts configuration values.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// New returns a Cipher with 32 bytes key.
+func New(key []byte) (*Cipher, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid key size %d, want %d", len(key), keySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aead: %w", err)
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// FromEnv returns a Cipher with the key from KeyEnv.
+func FromEnv() (*Cipher, error) {
+	encoded, err := env.Read(KeyEnv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+
+	if encoded == "" {
+		return nil, ErrNoKey
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key: %w", err)
+	}
+
+	return New(key)
+}
+
+// GenerateKey returns a new base64 encoded key.
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsEncrypted reports whether value is encrypted.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Encrypt returns the encrypted value of key name with Prefix.
+func (c *Cipher) Encrypt(name, value string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), []byte(name))
+
+	return Prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the decrypted value of key name. A value without
+// Prefix is returned as is.
+func (c *Cipher) Decrypt(name, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(value[len(Prefix):])
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrMalformed, err)
+	}
+
+	if len(sealed) < c.aead.NonceSize() {
+		return "", ErrMalformed
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+
+	plain, err := c.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	return string(plain), nil
+}
+
+// Conf returns a copy of conf with decrypted values. Keys which could not
+// be decrypted are dropped and the first error is returned. A nil Cipher
+// drops all encrypted values.
+func (c *Cipher) Conf(conf map[string]string) (map[string]string, error) {
+	var firstErr error
+
+	res := make(map[string]string, len(conf))
+
+	for k, v := range conf {
+		if !IsEncrypted(v) {
+			res[k] = v
+
+			continue
+		}
+
+		if c == nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to decrypt %s: %w", k, ErrNoKey)
+			}
+
+			continue
+		}
+
+		val, err := c.Decrypt(k, v)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to decrypt %s: %w", k, err)
+			}
+
+			continue
+		}
+
+		res[k] = val
+	}
+
+	return res, firstErr
+}
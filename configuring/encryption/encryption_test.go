@@ -0,0 +1,104 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newCipher(t *testing.T) *Cipher {
+	t.Helper()
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %s", err)
+	}
+
+	os.Setenv(KeyEnv, key)
+	defer os.Unsetenv(KeyEnv)
+
+	c, err := FromEnv()
+	if err != nil {
+		t.Fatalf("FromEnv() error = %s", err)
+	}
+
+	return c
+}
+
+func TestCipher_EncryptDecrypt(t *testing.T) {
+	c := newCipher(t)
+
+	encrypted, err := c.Encrypt("client/mysql/password", "secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %s", err)
+	}
+
+	if !strings.HasPrefix(encrypted, Prefix) {
+		t.Errorf("Encrypt() = %s, want prefix %s", encrypted, Prefix)
+	}
+
+	got, err := c.Decrypt("client/mysql/password", encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %s", err)
+	}
+
+	if got != "secret" {
+		t.Errorf("Decrypt() = %s, want secret", got)
+	}
+
+	if _, err := newCipher(t).Decrypt("client/mysql/password", encrypted); err == nil {
+		t.Errorf("Decrypt() with another key expected error")
+	}
+
+	if _, err := c.Decrypt("client/redis/password", encrypted); err == nil {
+		t.Errorf("Decrypt() under another name expected error")
+	}
+
+	if _, err := c.Decrypt("client/mysql/password", Prefix+base64.StdEncoding.EncodeToString([]byte("x"))); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Decrypt() error = %v, want %v", err, ErrMalformed)
+	}
+}
+
+func TestCipher_Conf(t *testing.T) {
+	c := newCipher(t)
+
+	encrypted, err := c.Encrypt("client/mysql/password", "secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %s", err)
+	}
+
+	conf := map[string]string{
+		"client/mysql/user":     "root",
+		"client/mysql/password": encrypted,
+	}
+
+	got, err := c.Conf(conf)
+	if err != nil {
+		t.Fatalf("Conf() error = %s", err)
+	}
+
+	if got["client/mysql/user"] != "root" || got["client/mysql/password"] != "secret" {
+		t.Errorf("Conf() = %v", got)
+	}
+
+	var noKey *Cipher
+
+	got, err = noKey.Conf(conf)
+	if !errors.Is(err, ErrNoKey) {
+		t.Errorf("Conf() error = %v, want %v", err, ErrNoKey)
+	}
+
+	if _, ok := got["client/mysql/password"]; ok || got["client/mysql/user"] != "root" {
+		t.Errorf("Conf() = %v", got)
+	}
+}
+
+func TestFromEnv_NoKey(t *testing.T) {
+	os.Unsetenv(KeyEnv)
+
+	if _, err := FromEnv(); !errors.Is(err, ErrNoKey) {
+		t.Errorf("FromEnv() error = %v, want %v", err, ErrNoKey)
+	}
+}
@@ -21,6 +21,9 @@ import (
 	"strings"
 
 	"github.com/imega/daemon"
+	"github.com/imega/daemon/configuring/coalesce"
+	"github.com/imega/daemon/configuring/instance"
+	"github.com/imega/daemon/configuring/secrets"
 )
 
 const suffix = "_FILE"
@@ -69,16 +72,50 @@ func Read(key string) (string, error) {
 }
 
 type Watcher struct {
-	f []daemon.WatcherConfigFunc
+	f          []daemon.WatcherConfigFunc
+	secrets    *secrets.Set
+	instanceID string
+	batch      *coalesce.Coalescer
 }
 
 // Once .
 func Once(f ...daemon.WatcherConfigFunc) *Watcher {
-	return &Watcher{f}
+	return &Watcher{f: f, instanceID: instance.ID()}
+}
+
+// Option configures the Watcher.
+type Option func(*Watcher)
+
+// WithSecrets sets resolvers of references in values,
+// file and env references are resolved by default.
+func WithSecrets(s *secrets.Set) Option {
+	return func(w *Watcher) {
+		w.secrets = s
+	}
+}
+
+// WithInstanceID sets the id of the instance-scoped overlay. The variable
+// PREFIX_MAIN_KEY_INSTANCES_<ID>_KEY overrides PREFIX_MAIN_KEY_KEY.
+// By default it is instance.ID().
+func WithInstanceID(id string) Option {
+	return func(w *Watcher) {
+		w.instanceID = id
+	}
+}
+
+// With applies options to the Watcher. It must be called before Read.
+func (w *Watcher) With(opts ...Option) *Watcher {
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
 }
 
 const secondEqual = 2
 
+// Read reads the environment and applies configs. It may be called again,
+// on SIGHUP for example, to apply the changed values of _FILE variables.
 func (w *Watcher) Read() error {
 	envKeys := []string{}
 
@@ -93,38 +130,90 @@ func (w *Watcher) Read() error {
 		envKeys = append(envKeys, strs[0])
 	}
 
+	if w.batch == nil {
+		w.batch = coalesce.New(0)
+	}
+
+	refs := w.secrets
+	if refs == nil {
+		refs = secrets.New()
+	}
+
 	for _, fn := range w.f {
 		mapKeys := make(map[string]string)
+		sources := make(map[string]string)
 		wConf := fn()
+		member := w.batch.Add(wConf)
 
 		for _, key := range wConf.Keys {
-			pre := strings.ReplaceAll(wConf.Prefix+"_"+wConf.MainKey+"_"+key, "-", "_")
-			pre = strings.ToUpper(strings.ReplaceAll(pre, "/", "_"))
+			pre := Name(wConf.Prefix, wConf.MainKey, key)
+
+			if w.instanceID != "" {
+				name := instance.EnvName(wConf.Prefix, wConf.MainKey, w.instanceID, key)
+				if env, ok := hasEnv(envKeys, name); ok {
+					if v, _ := Read(env); v != "" {
+						e := wConf.Prefix + "/" + wConf.MainKey + "/" + key
+						mapKeys[e] = v
+						sources[e] = source(refs, env, v)
+
+						continue
+					}
+				}
+			}
 
 			if env, ok := hasEnv(envKeys, pre); ok {
 				v, _ := Read(env)
 				if v != "" {
 					e := wConf.Prefix + "/" + wConf.MainKey + "/" + key
 					mapKeys[e] = v
+					sources[e] = source(refs, env, v)
 				}
 			} else {
-				if env, ok := hasPrefixEnv(envKeys, pre); ok {
+				for _, env := range prefixEnvs(envKeys, pre) {
 					v, _ := Read(env)
 					if v != "" {
 						e := wConf.Prefix + "/" + wConf.MainKey + "/" + key
-						suffix := "/" + strings.ToLower(env[len(e)+1:])
+						suffix := "/" + strings.ToLower(env[len(pre)+1:])
 						mapKeys[e+suffix] = v
+						sources[e+suffix] = source(refs, env, v)
 					}
 				}
 			}
 		}
 
-		wConf.ApplyFunc(mapKeys, nil)
+		conf, err := refs.Conf(mapKeys)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", member, err)
+		}
+
+		w.batch.Stage(member, conf, sources)
 	}
 
+	w.batch.Flush()
+
 	return nil
 }
 
+func source(refs *secrets.Set, env, value string) string {
+	if scheme, ok := refs.Source(value); ok {
+		return scheme
+	}
+
+	if os.Getenv(env+suffix) != "" {
+		return daemon.SourceFile
+	}
+
+	return daemon.SourceEnv
+}
+
+// Name returns the name of environment variable of key,
+// my-daemon/http-server/read-timeout is MY_DAEMON_HTTP_SERVER_READ_TIMEOUT.
+func Name(prefix, mainKey, key string) string {
+	name := strings.ReplaceAll(prefix+"_"+mainKey+"_"+key, "-", "_")
+
+	return strings.ToUpper(strings.ReplaceAll(name, "/", "_"))
+}
+
 func hasEnv(envkeys []string, val string) (string, bool) {
 	for _, v := range envkeys {
 		if v == val {
@@ -135,12 +224,16 @@ func hasEnv(envkeys []string, val string) (string, bool) {
 	return "", false
 }
 
-func hasPrefixEnv(envkeys []string, val string) (string, bool) {
+// prefixEnvs returns the names of variables of subkeys of val,
+// e.g. MY_DAEMON_LOG_COMPONENTS_GRPC of MY_DAEMON_LOG_COMPONENTS.
+func prefixEnvs(envkeys []string, val string) []string {
+	var res []string
+
 	for _, v := range envkeys {
-		if strings.HasPrefix(v, val) {
-			return v, true
+		if strings.HasPrefix(v, val+"_") {
+			res = append(res, v)
 		}
 	}
 
-	return "", false
+	return res
 }
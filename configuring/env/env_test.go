@@ -123,3 +123,80 @@ func Test_watcher_Read(t *testing.T) {
 		})
 	}
 }
+
+func Test_watcher_Read_instanceOverlay(t *testing.T) {
+	var actual map[string]string
+
+	os.Setenv("MY_DAEMON_GRPC_HOST", "0.0.0.0:9000")
+	os.Setenv("MY_DAEMON_GRPC_INSTANCES_CANARY_1_HOST", "0.0.0.0:9001")
+
+	w := Once(func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:  "my-daemon",
+			MainKey: "grpc",
+			Keys:    []string{"host"},
+			ApplyFunc: func(c, r map[string]string) {
+				actual = c
+			},
+		}
+	}).With(WithInstanceID("canary-1"))
+
+	if err := w.Read(); err != nil {
+		t.Errorf("watcher.Read() error = %v", err)
+	}
+
+	assert.Equal(t, map[string]string{"my-daemon/grpc/host": "0.0.0.0:9001"}, actual)
+}
+
+func Test_watcher_Read_withoutEnv(t *testing.T) {
+	calls := 0
+
+	w := Once(func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:    "my-daemon",
+			MainKey:   "no-env-server",
+			Keys:      []string{"host"},
+			ApplyFunc: func(c, r map[string]string) { calls++ },
+		}
+	})
+
+	if err := w.Read(); err != nil {
+		t.Fatalf("watcher.Read() error = %v", err)
+	}
+
+	if err := w.Read(); err != nil {
+		t.Fatalf("watcher.Read() error = %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("watcher.Read() applied %d times, want 1", calls)
+	}
+}
+
+func Test_watcher_Read_components(t *testing.T) {
+	var actual map[string]string
+
+	os.Setenv("MY_DAEMON_LOG_COMPONENTS_GRPC", "debug")
+	os.Setenv("MY_DAEMON_LOG_COMPONENTS_MYSQL", "warn")
+
+	defer os.Unsetenv("MY_DAEMON_LOG_COMPONENTS_GRPC")
+	defer os.Unsetenv("MY_DAEMON_LOG_COMPONENTS_MYSQL")
+
+	w := Once(func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:    "my-daemon",
+			MainKey:   "log",
+			Keys:      []string{"components"},
+			ApplyFunc: func(c, r map[string]string) { actual = c },
+		}
+	})
+
+	if err := w.Read(); err != nil {
+		t.Fatalf("watcher.Read() error = %v", err)
+	}
+
+	assert.Equal(t, map[string]string{
+		"my-daemon/log/components/grpc":  "debug",
+		"my-daemon/log/components/mysql": "warn",
+	}, actual)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/imega/daemon/configuring/inspect"
+)
+
+const redacted = "******"
+
+type introspection struct {
+	tracker *inspect.Tracker
+}
+
+// Handler returns an http.Handler
+//
+// It returns the effective config per WatcherConfig as JSON,
+// the values of secret keys are redacted.
+func Handler(opts ...Option) http.Handler {
+	handler := &introspection{}
+
+	for _, opt := range opts {
+		opt(handler)
+	}
+
+	return handler
+}
+
+// HandlerFunc returns an http.HandlerFunc.
+func HandlerFunc(opts ...Option) http.HandlerFunc {
+	return Handler(opts...).ServeHTTP
+}
+
+// Option adds optional parameter for the Handler.
+type Option func(*introspection)
+
+// WithTracker sets the tracker of applied configs.
+func WithTracker(t *inspect.Tracker) Option {
+	return func(i *introspection) {
+		i.tracker = t
+	}
+}
+
+func (i *introspection) ServeHTTP(resp http.ResponseWriter, r *http.Request) {
+	entries := []inspect.Entry{}
+
+	if i.tracker != nil {
+		entries = i.tracker.Entries()
+	}
+
+	for _, e := range entries {
+		for idx, k := range e.Keys {
+			if k.Secret && k.Value != "" {
+				e.Keys[idx].Value = redacted
+			}
+		}
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(resp).Encode(entries); err != nil {
+		http.Error(resp, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/configuring/coalesce"
+	"github.com/imega/daemon/configuring/inspect"
+)
+
+func TestHandler(t *testing.T) {
+	s := daemon.Schema{
+		{Name: "user", Type: daemon.TypeString},
+		{Name: "password", Type: daemon.TypeString, Secret: true},
+		{Name: "timeout", Type: daemon.TypeDuration},
+		{Name: "net", Type: daemon.TypeString, Default: "tcp"},
+	}
+
+	tracker := inspect.NewTracker()
+	f := tracker.Track(func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:    "client",
+			MainKey:   "mysql",
+			Keys:      s.Names(),
+			Schema:    s,
+			ApplyFunc: func(conf, reset map[string]string) {},
+		}
+	})
+
+	batch := coalesce.New(0)
+	key := batch.Add(f[0]())
+	batch.Update(
+		key,
+		map[string]string{
+			"client/mysql/user":     "root",
+			"client/mysql/password": "secret",
+			"client/mysql/timeout":  "ten",
+		},
+		map[string]string{
+			"client/mysql/user":     daemon.SourceConsul,
+			"client/mysql/password": daemon.SourceFile,
+			"client/mysql/timeout":  daemon.SourceConsul,
+		},
+	)
+
+	ht := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request")
+	}
+
+	HandlerFunc(WithTracker(tracker))(ht, req)
+
+	if ht.Code != http.StatusOK {
+		t.Fatalf("Handler() = %d, want %d", ht.Code, http.StatusOK)
+	}
+
+	entries := []inspect.Entry{}
+	if err := json.NewDecoder(ht.Body).Decode(&entries); err != nil {
+		t.Fatalf("failed to decode response, %s", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("Handler() returns %d entries, want 1", len(entries))
+	}
+
+	e := entries[0]
+	if e.Valid || len(e.Errors) != 1 || e.AppliedAt.IsZero() {
+		t.Errorf("Handler() entry = %+v, want one validation error", e)
+	}
+
+	want := []inspect.Key{
+		{Key: "client/mysql/net", Value: "tcp", Source: daemon.SourceDefault},
+		{Key: "client/mysql/password", Value: redacted, Source: daemon.SourceFile, Secret: true},
+		{Key: "client/mysql/timeout", Value: "ten", Source: daemon.SourceConsul},
+		{Key: "client/mysql/user", Value: "root", Source: daemon.SourceConsul},
+	}
+
+	if len(e.Keys) != len(want) {
+		t.Fatalf("Handler() keys = %+v, want %+v", e.Keys, want)
+	}
+
+	for i := range want {
+		if e.Keys[i] != want[i] {
+			t.Errorf("Handler() key = %+v, want %+v", e.Keys[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inspect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/logging"
+)
+
+// Entry is the effective config of WatcherConfig.
+type Entry struct {
+	Prefix    string    `json:"prefix"`
+	MainKey   string    `json:"main_key"`
+	AppliedAt time.Time `json:"applied_at"`
+	Valid     bool      `json:"valid"`
+	Errors    []string  `json:"errors,omitempty"`
+	Keys      []Key     `json:"keys"`
+}
+
+// Key is an effective value of key.
+type Key struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// Tracker records configs applied to WatcherConfigs.
+//
+// # Example
+//
+// tracker := inspect.NewTracker()
+// cr := consul.Watch(log, tracker.Track(h.WatcherConfigFunc, g.WatcherConfigFunc)...).
+type Tracker struct {
+	mx      sync.RWMutex
+	members []*member
+}
+
+type member struct {
+	wConf     daemon.WatcherConfig
+	conf      map[string]string
+	sources   map[string]string
+	appliedAt time.Time
+}
+
+// NewTracker returns a Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Track returns the WatcherConfigFuncs which record applied configs.
+func (t *Tracker) Track(f ...daemon.WatcherConfigFunc) []daemon.WatcherConfigFunc {
+	res := make([]daemon.WatcherConfigFunc, 0, len(f))
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	for _, fn := range f {
+		wConf := fn()
+		apply := wConf.ChangesFunc()
+
+		t.members = append(t.members, &member{wConf: wConf})
+
+		wConf.ApplyChanges = func(cs daemon.ChangeSet) {
+			t.record(wConf, cs)
+			apply(cs)
+		}
+
+		res = append(res, func() daemon.WatcherConfig { return wConf })
+	}
+
+	return res
+}
+
+func (t *Tracker) record(wConf daemon.WatcherConfig, cs daemon.ChangeSet) {
+	now := time.Now()
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	for _, m := range t.members {
+		if !sameGroup(m.wConf, wConf) {
+			continue
+		}
+
+		base := m.wConf.Prefix + "/" + m.wConf.MainKey + "/"
+		m.conf = make(map[string]string)
+		m.sources = make(map[string]string)
+		m.appliedAt = now
+
+		for k, v := range cs.Conf {
+			if strings.HasPrefix(k, base) {
+				m.conf[k] = v
+				m.sources[k] = cs.Sources[k]
+			}
+		}
+	}
+}
+
+func sameGroup(a, b daemon.WatcherConfig) bool {
+	if a.Group != "" || b.Group != "" {
+		return a.Group == b.Group
+	}
+
+	return a.Prefix == b.Prefix && a.MainKey == b.MainKey
+}
+
+// Entries returns the effective configs. Keys which are not set
+// have values by default.
+func (t *Tracker) Entries() []Entry {
+	t.mx.RLock()
+	defer t.mx.RUnlock()
+
+	entries := make([]Entry, 0, len(t.members))
+
+	for _, m := range t.members {
+		entries = append(entries, m.entry())
+	}
+
+	return entries
+}
+
+func (m *member) entry() Entry {
+	base := m.wConf.Prefix + "/" + m.wConf.MainKey + "/"
+	e := Entry{
+		Prefix:    m.wConf.Prefix,
+		MainKey:   m.wConf.MainKey,
+		AppliedAt: m.appliedAt,
+		Keys:      []Key{},
+	}
+
+	for k, v := range m.conf {
+		name := k[len(base):]
+		ks, known := m.wConf.Lookup(name)
+
+		source := m.sources[k]
+		if source == "" {
+			source = "unknown"
+		}
+
+		e.Keys = append(e.Keys, Key{
+			Key:    k,
+			Value:  v,
+			Source: source,
+			Secret: ks.Secret || (!known && IsSecretName(name)),
+		})
+
+		if !known {
+			e.Errors = append(e.Errors, fmt.Sprintf("%s: unknown key", k))
+
+			continue
+		}
+
+		if err := Validate(ks.Type, v); err != nil {
+			e.Errors = append(e.Errors, fmt.Sprintf("%s: %s", k, err))
+		}
+	}
+
+	for _, name := range m.wConf.Keys {
+		if isSet(m.conf, base+name) {
+			continue
+		}
+
+		ks, _ := m.wConf.Schema.Lookup(name)
+
+		e.Keys = append(e.Keys, Key{
+			Key:    base + name,
+			Value:  ks.Default,
+			Source: daemon.SourceDefault,
+			Secret: ks.Secret,
+		})
+	}
+
+	sort.Slice(e.Keys, func(i, j int) bool { return e.Keys[i].Key < e.Keys[j].Key })
+	sort.Strings(e.Errors)
+
+	e.Valid = len(e.Errors) == 0
+
+	return e
+}
+
+func isSet(conf map[string]string, key string) bool {
+	for k := range conf {
+		if k == key || strings.HasPrefix(k, key+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsSecretName reports whether the name of key looks like a secret,
+// the names are the same as masked in logs, see logging.DefaultRedactedFields.
+func IsSecretName(name string) bool {
+	return logging.IsSensitiveName(name)
+}
+
+var errInvalidJSON = errors.New("invalid json")
+
+// Validate checks value has type of schema.
+func Validate(typ, value string) error {
+	var err error
+
+	switch typ {
+	case daemon.TypeInt:
+		_, err = strconv.Atoi(value)
+	case daemon.TypeFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case daemon.TypeBool:
+		_, err = strconv.ParseBool(value)
+	case daemon.TypeDuration:
+		_, err = time.ParseDuration(value)
+	case daemon.TypeJSON:
+		if !json.Valid([]byte(value)) {
+			err = errInvalidJSON
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", typ, err)
+	}
+
+	return nil
+}
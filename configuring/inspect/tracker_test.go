@@ -0,0 +1,197 @@
+package inspect_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/configuring/inspect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker_Entries(t *testing.T) {
+	mysql := func(group string) daemon.WatcherConfigFunc {
+		return func() daemon.WatcherConfig {
+			return daemon.WatcherConfig{
+				Prefix:  "client",
+				MainKey: "mysql",
+				Keys:    []string{"host", "password", "timeout"},
+				Schema: daemon.Schema{
+					{Name: "password", Type: daemon.TypeString, Secret: true},
+					{Name: "timeout", Type: daemon.TypeDuration, Default: "1s"},
+				},
+				Group:     group,
+				ApplyFunc: func(conf, reset map[string]string) {},
+			}
+		}
+	}
+
+	instance := func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:    "instance",
+			MainKey:   "mysql",
+			Keys:      []string{"host"},
+			Group:     "mysql",
+			ApplyFunc: func(conf, reset map[string]string) {},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		funcs   []daemon.WatcherConfigFunc
+		conf    map[string]string
+		sources map[string]string
+		want    []inspect.Entry
+	}{
+		{
+			name:  "defaults",
+			funcs: []daemon.WatcherConfigFunc{mysql("")},
+			want: []inspect.Entry{
+				{
+					Prefix: "client", MainKey: "mysql", Valid: true,
+					Keys: []inspect.Key{
+						{Key: "client/mysql/host", Source: daemon.SourceDefault},
+						{Key: "client/mysql/password", Source: daemon.SourceDefault, Secret: true},
+						{Key: "client/mysql/timeout", Value: "1s", Source: daemon.SourceDefault},
+					},
+				},
+			},
+		},
+		{
+			name:  "applied config",
+			funcs: []daemon.WatcherConfigFunc{mysql("")},
+			conf: map[string]string{
+				"client/mysql/host/instance-1": "db-1:3306",
+				"client/mysql/password":        "secret",
+				"client/mysql/timeout":         "2s",
+			},
+			sources: map[string]string{
+				"client/mysql/host/instance-1": daemon.SourceConsul,
+				"client/mysql/password":        daemon.SourceFile,
+			},
+			want: []inspect.Entry{
+				{
+					Prefix: "client", MainKey: "mysql", Valid: true,
+					Keys: []inspect.Key{
+						{Key: "client/mysql/host/instance-1", Value: "db-1:3306", Source: daemon.SourceConsul},
+						{Key: "client/mysql/password", Value: "secret", Source: daemon.SourceFile, Secret: true},
+						{Key: "client/mysql/timeout", Value: "2s", Source: "unknown"},
+					},
+				},
+			},
+		},
+		{
+			name:  "unknown key and invalid value",
+			funcs: []daemon.WatcherConfigFunc{mysql("")},
+			conf: map[string]string{
+				"client/mysql/api-token": "qwerty",
+				"client/mysql/timeout":   "ten",
+			},
+			want: []inspect.Entry{
+				{
+					Prefix: "client", MainKey: "mysql",
+					Errors: []string{
+						"client/mysql/api-token: unknown key",
+						`client/mysql/timeout: invalid duration: time: invalid duration "ten"`,
+					},
+					Keys: []inspect.Key{
+						{Key: "client/mysql/api-token", Value: "qwerty", Source: "unknown", Secret: true},
+						{Key: "client/mysql/host", Source: daemon.SourceDefault},
+						{Key: "client/mysql/password", Source: daemon.SourceDefault, Secret: true},
+						{Key: "client/mysql/timeout", Value: "ten", Source: "unknown"},
+					},
+				},
+			},
+		},
+		{
+			name:  "group config is attributed to every member",
+			funcs: []daemon.WatcherConfigFunc{instance, mysql("mysql")},
+			conf: map[string]string{
+				"instance/mysql/host":   "db:3306",
+				"client/mysql/password": "secret",
+			},
+			want: []inspect.Entry{
+				{
+					Prefix: "instance", MainKey: "mysql", Valid: true,
+					Keys: []inspect.Key{
+						{Key: "instance/mysql/host", Value: "db:3306", Source: "unknown"},
+					},
+				},
+				{
+					Prefix: "client", MainKey: "mysql", Valid: true,
+					Keys: []inspect.Key{
+						{Key: "client/mysql/host", Source: daemon.SourceDefault},
+						{Key: "client/mysql/password", Value: "secret", Source: "unknown", Secret: true},
+						{Key: "client/mysql/timeout", Value: "1s", Source: daemon.SourceDefault},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := inspect.NewTracker()
+			f := tracker.Track(tt.funcs...)
+
+			if tt.conf != nil {
+				cs := daemon.NewChangeSet(tt.conf, nil)
+				cs.Sources = tt.sources
+				f[0]().ChangesFunc()(cs)
+			}
+
+			got := tracker.Entries()
+			for i := range got {
+				got[i].AppliedAt = time.Time{}
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		typ, value string
+		wantErr    bool
+	}{
+		{typ: daemon.TypeString, value: "any"},
+		{typ: daemon.TypeInt, value: "10"},
+		{typ: daemon.TypeInt, value: "1.5", wantErr: true},
+		{typ: daemon.TypeFloat, value: "1.5"},
+		{typ: daemon.TypeFloat, value: "fast", wantErr: true},
+		{typ: daemon.TypeBool, value: "true"},
+		{typ: daemon.TypeBool, value: "yes", wantErr: true},
+		{typ: daemon.TypeDuration, value: "5s"},
+		{typ: daemon.TypeDuration, value: "5", wantErr: true},
+		{typ: daemon.TypeJSON, value: `{"a":1}`},
+		{typ: daemon.TypeJSON, value: `{a:1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ+" "+tt.value, func(t *testing.T) {
+			if err := inspect.Validate(tt.typ, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsSecretName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "password", want: true},
+		{name: "dsn", want: true},
+		{name: "api-token", want: true},
+		{name: "api-key", want: true},
+		{name: "host", want: false},
+		{name: "timeout", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inspect.IsSecretName(tt.name); got != tt.want {
+				t.Errorf("IsSecretName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
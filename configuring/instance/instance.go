@@ -0,0 +1,75 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instance
+
+import (
+	"os"
+	"strings"
+)
+
+// Marker separates the instance-scoped overlay from the shared keys,
+// prefix/main-key/@instances/<id>/key overrides prefix/main-key/key
+// for the instance <id>.
+const Marker = "@instances"
+
+// IDEnv is the name of environment variable with the instance id.
+const IDEnv = "INSTANCE_ID"
+
+// ID returns the id of this instance, INSTANCE_ID or the hostname.
+func ID() string {
+	if id := os.Getenv(IDEnv); id != "" {
+		return id
+	}
+
+	hostname, _ := os.Hostname()
+
+	return hostname
+}
+
+// Merge returns the keys of conf under base with the overlay of instance id
+// merged over them. The overlays of other instances are dropped.
+func Merge(base, id string, conf map[string]string) map[string]string {
+	overlays := base + "/" + Marker + "/"
+	own := overlays + id + "/"
+	res := make(map[string]string, len(conf))
+
+	for k, v := range conf {
+		if !strings.HasPrefix(k, overlays) {
+			res[k] = v
+		}
+	}
+
+	if id == "" {
+		return res
+	}
+
+	for k, v := range conf {
+		if strings.HasPrefix(k, own) {
+			res[base+"/"+k[len(own):]] = v
+		}
+	}
+
+	return res
+}
+
+// EnvName returns the name of environment variable that overrides
+// the shared one for the instance id,
+// PREFIX_MAIN_KEY_INSTANCES_<ID>_KEY for PREFIX_MAIN_KEY_KEY.
+func EnvName(prefix, mainKey, id, key string) string {
+	name := prefix + "_" + mainKey + "_INSTANCES_" + id + "_" + key
+	name = strings.NewReplacer("-", "_", "/", "_", ".", "_").Replace(name)
+
+	return strings.ToUpper(name)
+}
@@ -0,0 +1,58 @@
+package instance
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	conf := map[string]string{
+		"client/redis-sentinel/pool-size":                       "10",
+		"client/redis-sentinel/db":                              "1",
+		"client/redis-sentinel/@instances/canary-1/pool-size":   "20",
+		"client/redis-sentinel/@instances/canary-1/max-retries": "3",
+		"client/redis-sentinel/@instances/canary-2/pool-size":   "30",
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		want map[string]string
+	}{
+		{
+			name: "instance with overlay",
+			id:   "canary-1",
+			want: map[string]string{
+				"client/redis-sentinel/pool-size":   "20",
+				"client/redis-sentinel/db":          "1",
+				"client/redis-sentinel/max-retries": "3",
+			},
+		},
+		{
+			name: "instance without overlay",
+			id:   "replica",
+			want: map[string]string{
+				"client/redis-sentinel/pool-size": "10",
+				"client/redis-sentinel/db":        "1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge("client/redis-sentinel", tt.id, conf)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	got := EnvName("my-daemon", "http-server", "pod-1.local", "read-timeout")
+	want := "MY_DAEMON_HTTP_SERVER_INSTANCES_POD_1_LOCAL_READ_TIMEOUT"
+
+	if got != want {
+		t.Errorf("EnvName() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/configuring/env"
+)
+
+// Entry is a key of the configuration reference.
+type Entry struct {
+	ConsulKey string `json:"consul_key"`
+	EnvVar    string `json:"env_var"`
+
+	daemon.KeySchema
+}
+
+// Collect aggregates the schemas of WatcherConfigs. Keys without schema
+// are described by their names only.
+func Collect(f ...daemon.WatcherConfigFunc) []Entry {
+	entries := []Entry{}
+
+	for _, fn := range f {
+		wConf := fn()
+
+		for _, name := range wConf.Keys {
+			ks, ok := wConf.Schema.Lookup(name)
+			if !ok {
+				ks = daemon.KeySchema{Name: name, Type: daemon.TypeString}
+			}
+
+			entries = append(entries, Entry{
+				ConsulKey: wConf.Prefix + "/" + wConf.MainKey + "/" + name,
+				EnvVar:    env.Name(wConf.Prefix, wConf.MainKey, name),
+				KeySchema: ks,
+			})
+		}
+	}
+
+	return entries
+}
+
+// WriteJSON writes the reference as JSON.
+func WriteJSON(w io.Writer, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(entries); err != nil {
+		return fmt.Errorf("failed to encode entries: %w", err)
+	}
+
+	return nil
+}
+
+// WriteMarkdown writes the reference as Markdown table.
+func WriteMarkdown(w io.Writer, entries []Entry) error {
+	b := &strings.Builder{}
+
+	b.WriteString("| Consul key | Env var | Type | Default | Secret | Description |\n")
+	b.WriteString("|---|---|---|---|---|---|\n")
+
+	for _, e := range entries {
+		secret := ""
+		if e.Secret {
+			secret = "yes"
+		}
+
+		fmt.Fprintf(
+			b,
+			"| `%s` | `%s` | %s | %s | %s | %s |\n",
+			e.ConsulKey,
+			e.EnvVar,
+			e.Type,
+			markdownCode(e.Default),
+			secret,
+			strings.ReplaceAll(e.Description, "|", `\|`),
+		)
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write entries: %w", err)
+	}
+
+	return nil
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return "`" + s + "`"
+}
+
+// Unknown returns the sorted keys of conf under the prefix of wConf
+// which are not declared in its Keys. A declared key also covers
+// its subkeys, host covers host/instance-1.
+func Unknown(wConf daemon.WatcherConfig, conf map[string]string) []string {
+	base := wConf.Prefix + "/" + wConf.MainKey + "/"
+	unknown := []string{}
+
+	for k := range conf {
+		if !strings.HasPrefix(k, base) {
+			continue
+		}
+
+		if _, ok := wConf.Lookup(k[len(base):]); !ok {
+			unknown = append(unknown, k)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	return unknown
+}
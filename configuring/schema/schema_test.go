@@ -0,0 +1,92 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/imega/daemon"
+)
+
+func watcherConfig() daemon.WatcherConfig {
+	s := daemon.Schema{
+		{Name: "host", Type: daemon.TypeString, Description: "Address."},
+		{Name: "password", Type: daemon.TypeString, Description: "Password.", Secret: true},
+		{Name: "timeout", Type: daemon.TypeDuration, Default: "2s", Description: "Timeout."},
+	}
+
+	return daemon.WatcherConfig{
+		Prefix:  "my-daemon",
+		MainKey: "redis-sentinel",
+		Keys:    s.Names(),
+		Schema:  s,
+	}
+}
+
+func TestCollect(t *testing.T) {
+	entries := Collect(watcherConfig)
+
+	if len(entries) != 3 {
+		t.Fatalf("Collect() returns %d entries, want 3", len(entries))
+	}
+
+	want := Entry{
+		ConsulKey: "my-daemon/redis-sentinel/timeout",
+		EnvVar:    "MY_DAEMON_REDIS_SENTINEL_TIMEOUT",
+		KeySchema: daemon.KeySchema{
+			Name:        "timeout",
+			Type:        daemon.TypeDuration,
+			Default:     "2s",
+			Description: "Timeout.",
+		},
+	}
+	if !reflect.DeepEqual(entries[2], want) {
+		t.Errorf("Collect() = %+v, want %+v", entries[2], want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	entries := Collect(watcherConfig)
+
+	buf := &bytes.Buffer{}
+	if err := WriteMarkdown(buf, entries); err != nil {
+		t.Fatalf("WriteMarkdown() error = %s", err)
+	}
+
+	row := "| `my-daemon/redis-sentinel/password` | `MY_DAEMON_REDIS_SENTINEL_PASSWORD` | string |  | yes | Password. |"
+	if !strings.Contains(buf.String(), row) {
+		t.Errorf("WriteMarkdown() = %s, want row %s", buf.String(), row)
+	}
+
+	buf.Reset()
+
+	if err := WriteJSON(buf, entries); err != nil {
+		t.Fatalf("WriteJSON() error = %s", err)
+	}
+
+	actual := []Entry{}
+	if err := json.Unmarshal(buf.Bytes(), &actual); err != nil {
+		t.Fatalf("failed to decode json, %s", err)
+	}
+
+	if !reflect.DeepEqual(actual, entries) {
+		t.Errorf("WriteJSON() = %+v, want %+v", actual, entries)
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	got := Unknown(watcherConfig(), map[string]string{
+		"my-daemon/redis-sentinel/host/instance-1": "127.0.0.1:26379",
+		"my-daemon/redis-sentinel/password":        "secret",
+		"my-daemon/redis-sentinel/pasword":         "secret",
+		"my-daemon/redis-sentinel/pool":            "10",
+		"my-daemon/mysql/user":                     "root",
+	})
+
+	want := []string{"my-daemon/redis-sentinel/pasword", "my-daemon/redis-sentinel/pool"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unknown() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Resolver returns the value which ref refers to.
+type Resolver interface {
+	Resolve(ref *url.URL) (string, error)
+}
+
+// ResolverFunc is an adapter to allow the use of ordinary functions
+// as Resolver.
+type ResolverFunc func(ref *url.URL) (string, error)
+
+// Resolve calls f(ref).
+func (f ResolverFunc) Resolve(ref *url.URL) (string, error) {
+	return f(ref)
+}
+
+// Set resolves references in configuration values by their scheme.
+// A value with unknown scheme is returned as is.
+//
+// file:///run/secrets/mypassword  content of the file
+// env://PASSWORD                  value of the environment variable
+// vault://secret/data/db#password field of the Vault secret, see Vault.
+type Set struct {
+	mx        sync.RWMutex
+	resolvers map[string]Resolver
+}
+
+// New returns a Set with file and env resolvers. Use it for trusted
+// sources only, e.g. environment, see NewEmpty.
+func New() *Set {
+	s := NewEmpty()
+
+	s.Register("file", ResolverFunc(resolveFile))
+	s.Register("env", ResolverFunc(resolveEnv))
+
+	return s
+}
+
+// NewEmpty returns a Set without resolvers, add them by Register.
+// The values of Consul are resolved by it, anyone who can write to Consul
+// must not be able to read the files or the environment of container.
+func NewEmpty() *Set {
+	return &Set{resolvers: make(map[string]Resolver)}
+}
+
+// Register adds the resolver of scheme.
+func (s *Set) Register(scheme string, r Resolver) *Set {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.resolvers[scheme] = r
+
+	return s
+}
+
+// Source returns the scheme of value if it is a reference.
+func (s *Set) Source(value string) (string, bool) {
+	scheme, _, ok := s.lookup(value)
+
+	return scheme, ok
+}
+
+func (s *Set) lookup(value string) (string, Resolver, bool) {
+	i := strings.Index(value, "://")
+	if i <= 0 {
+		return "", nil, false
+	}
+
+	scheme := value[:i]
+
+	s.mx.RLock()
+	r, ok := s.resolvers[scheme]
+	s.mx.RUnlock()
+
+	return scheme, r, ok
+}
+
+// Value resolves value if it is a reference.
+func (s *Set) Value(value string) (string, error) {
+	_, r, ok := s.lookup(value)
+	if !ok {
+		return value, nil
+	}
+
+	ref, err := url.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse reference: %w", err)
+	}
+
+	return r.Resolve(ref)
+}
+
+// Conf returns a copy of conf with resolved references. Keys which
+// could not be resolved are dropped and the first error is returned.
+func (s *Set) Conf(conf map[string]string) (map[string]string, error) {
+	var firstErr error
+
+	res := make(map[string]string, len(conf))
+
+	for k, v := range conf {
+		val, err := s.Value(v)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to resolve %s: %w", k, err)
+			}
+
+			continue
+		}
+
+		res[k] = val
+	}
+
+	return res, firstErr
+}
+
+// ErrEmptyReference is returned if reference has no name.
+var ErrEmptyReference = errors.New("empty reference")
+
+func resolveFile(ref *url.URL) (string, error) {
+	filename := ref.Host + ref.Path
+	if filename == "" {
+		return "", ErrEmptyReference
+	}
+
+	value, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+
+	return strings.TrimSpace(string(value)), nil
+}
+
+func resolveEnv(ref *url.URL) (string, error) {
+	if ref.Host == "" {
+		return "", ErrEmptyReference
+	}
+
+	return os.Getenv(ref.Host), nil
+}
@@ -0,0 +1,118 @@
+package secrets
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestSet_Value(t *testing.T) {
+	tmpfile, err := ioutil.TempFile(os.TempDir(), "secret.")
+	if err != nil {
+		t.Fatalf("failed to create temp file, %s", err)
+	}
+
+	defer os.Remove(tmpfile.Name())
+
+	if _, err := tmpfile.WriteString("from-file\n"); err != nil {
+		t.Fatalf("failed to write to temp file, %s", err)
+	}
+
+	os.Setenv("TEST_SECRET_VALUE", "from-env")
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain value", value: "value", want: "value"},
+		{name: "unknown scheme", value: "tcp://127.0.0.1:3306", want: "tcp://127.0.0.1:3306"},
+		{name: "file", value: "file://" + tmpfile.Name(), want: "from-file"},
+		{name: "env", value: "env://TEST_SECRET_VALUE", want: "from-env"},
+		{name: "missing file", value: "file:///not/exists", wantErr: true},
+	}
+
+	s := New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Value(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Value() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Value() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet_Conf(t *testing.T) {
+	os.Setenv("TEST_SECRET_PASSWORD", "secret")
+
+	got, err := New().Conf(map[string]string{
+		"client/mysql/user":     "root",
+		"client/mysql/password": "env://TEST_SECRET_PASSWORD",
+		"client/mysql/db-name":  "file:///not/exists",
+	})
+	if err == nil {
+		t.Errorf("Conf() expected error")
+	}
+
+	if len(got) != 2 || got["client/mysql/user"] != "root" || got["client/mysql/password"] != "secret" {
+		t.Errorf("Conf() = %v", got)
+	}
+}
+
+func TestVault_Resolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/mysql":
+			w.Write([]byte(`{"data":{"data":{"password":"kv2"},"metadata":{"version":1}}}`))
+		case "/v1/kv/mysql":
+			w.Write([]byte(`{"data":{"password":"kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	s := New().Register("vault", NewVault(srv.URL, "token"))
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{name: "kv version 2", value: "vault://secret/data/mysql#password", want: "kv2"},
+		{name: "kv version 1", value: "vault://kv/mysql#password", want: "kv1"},
+		{name: "unknown field", value: "vault://kv/mysql#user", wantErr: ErrFieldNotFound},
+		{name: "unknown secret", value: "vault://kv/redis#password", wantErr: ErrVaultStatus},
+		{name: "without field", value: "vault://kv/mysql", wantErr: ErrEmptyReference},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Value(tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Value() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Value() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
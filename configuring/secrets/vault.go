@@ -0,0 +1,104 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Vault resolves references to secrets of HashiCorp Vault,
+// vault://<path>#<field>. Both KV versions are supported,
+// for version 2 the path includes "data", vault://secret/data/db#password.
+type Vault struct {
+	Addr   string
+	Token  string
+	Client *http.Client
+}
+
+const defaultVaultTimeout = 10 * time.Second
+
+// NewVault returns a Vault resolver.
+func NewVault(addr, token string) *Vault {
+	return &Vault{
+		Addr:   strings.TrimRight(addr, "/"),
+		Token:  token,
+		Client: &http.Client{Timeout: defaultVaultTimeout},
+	}
+}
+
+var (
+	// ErrVaultStatus is returned if Vault responds with unexpected status.
+	ErrVaultStatus = errors.New("unexpected vault status")
+
+	// ErrFieldNotFound is returned if secret has no field.
+	ErrFieldNotFound = errors.New("field not found")
+)
+
+type vaultSecret struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// Resolve returns the field of secret.
+func (v *Vault) Resolve(ref *url.URL) (string, error) {
+	path := strings.Trim(ref.Host+ref.Path, "/")
+	if path == "" || ref.Fragment == "" {
+		return "", ErrEmptyReference
+	}
+
+	req, err := http.NewRequest(http.MethodGet, v.Addr+"/v1/"+path, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("X-Vault-Token", v.Token)
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request vault: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %d", ErrVaultStatus, resp.StatusCode)
+	}
+
+	secret := vaultSecret{}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+
+	data := secret.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		data = inner
+	}
+
+	value, ok := data[ref.Fragment]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrFieldNotFound, ref.Fragment)
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,91 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/imega/daemon/configuring/encryption"
+	"github.com/spf13/cobra"
+)
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt --key name [value]",
+	Short: "Encrypt a configuration value",
+	Long: `Encrypt a configuration value with the key from ` + encryption.KeyEnv + `
+(or ` + encryption.KeyEnv + `_FILE). The value is read from stdin if it is omitted.
+The value is bound to the full name of config key and can't be used under another key.`,
+	Example: "CONFIG_ENCRYPTION_KEY=... daemon-gen encrypt --key my-daemon/mysql/password mypassword\n" +
+		"daemon-gen encrypt --generate-key",
+	Args: cobra.MaximumNArgs(1),
+	Run:  encrypt,
+}
+
+func init() {
+	encryptCmd.Flags().Bool("generate-key", false, "print a new key and exit")
+	encryptCmd.Flags().String("key", "", "full name of config key, e.g. my-daemon/mysql/password")
+	rootCmd.AddCommand(encryptCmd)
+}
+
+func encrypt(cmd *cobra.Command, args []string) {
+	if generate, _ := cmd.Flags().GetBool("generate-key"); generate {
+		key, err := encryption.GenerateKey()
+		if err != nil {
+			fmt.Printf("failed to generate key: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println(key)
+
+		return
+	}
+
+	name, _ := cmd.Flags().GetString("key")
+	if name == "" {
+		fmt.Println("the name of config key is required, --key")
+		os.Exit(1)
+	}
+
+	c, err := encryption.FromEnv()
+	if err != nil {
+		fmt.Printf("failed to read key: %s\n", err)
+		os.Exit(1)
+	}
+
+	var value string
+
+	if len(args) > 0 {
+		value = args[0]
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Printf("failed to read value: %s\n", err)
+			os.Exit(1)
+		}
+
+		value = strings.TrimRight(line, "\r\n")
+	}
+
+	encrypted, err := c.Encrypt(name, value)
+	if err != nil {
+		fmt.Printf("failed to encrypt value: %s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(encrypted)
+}
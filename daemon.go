@@ -30,22 +30,48 @@ type daemon struct {
 	Log logging.Logger
 
 	sf []ShutdownFunc
+	df []ShutdownFunc
 	hf []HealthCheckFunc
+	rf []ReloadFunc
+
+	drainDelay time.Duration
 }
 
 // Daemon is a interface.
 type Daemon interface {
 	Run(shutdownTimeout time.Duration) error
 	RegisterShutdownFunc(f ...ShutdownFunc)
+	RegisterDrainFunc(f ...ShutdownFunc)
 	RegisterHealthCheckFunc(f HealthCheckFunc)
+	RegisterReloadFunc(f ...ReloadFunc)
+}
+
+// Option .
+type Option func(*daemon)
+
+// WithDrainDelay sets the pause between the drain functions and
+// the shutdown functions, so load balancers stop sending requests
+// before the servers stop.
+func WithDrainDelay(delay time.Duration) Option {
+	return func(d *daemon) {
+		d.drainDelay = delay
+	}
 }
 
-// New create a new Daemon.
-func New(l logging.Logger, cr ConfigReader) (Daemon, error) {
+// New create a new Daemon. The logs are discarded if l is nil.
+func New(l logging.Logger, cr ConfigReader, opts ...Option) (Daemon, error) {
+	if l == nil {
+		l = logging.GetNoopLog()
+	}
+
 	app := &daemon{
 		Log: l,
 	}
 
+	for _, opt := range opts {
+		opt(app)
+	}
+
 	if err := cr.Read(); err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
@@ -53,15 +79,39 @@ func New(l logging.Logger, cr ConfigReader) (Daemon, error) {
 	return app, nil
 }
 
-// Run daemon.
+// Run daemon. It calls the reload functions on SIGHUP
+// and shuts down on SIGTERM or SIGINT.
 func (d *daemon) Run(shutdownTimeout time.Duration) error {
 	sigchan := make(chan os.Signal, 1)
-	signal.Notify(sigchan, syscall.SIGTERM, syscall.SIGINT)
-	<-sigchan
+	signal.Notify(sigchan, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+
+	for sig := range sigchan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+
+		d.reload()
+	}
 
 	return d.shutdown(shutdownTimeout)
 }
 
+// ReloadFunc is called on SIGHUP.
+type ReloadFunc func()
+
+// RegisterReloadFunc .
+func (d *daemon) RegisterReloadFunc(f ...ReloadFunc) {
+	d.rf = append(d.rf, f...)
+}
+
+func (d *daemon) reload() {
+	d.Log.Debugf("daemon is reloading")
+
+	for _, f := range d.rf {
+		f()
+	}
+}
+
 // ShutdownFunc .
 type ShutdownFunc func()
 
@@ -70,10 +120,28 @@ func (d *daemon) RegisterShutdownFunc(f ...ShutdownFunc) {
 	d.sf = append(d.sf, f...)
 }
 
+// RegisterDrainFunc registers the functions which are called when
+// the shutdown begins, before the drain delay and the shutdown
+// functions, e.g. to fail readiness.
+func (d *daemon) RegisterDrainFunc(f ...ShutdownFunc) {
+	d.df = append(d.df, f...)
+}
+
 // ErrShutdownTimeout .
 var ErrShutdownTimeout = errors.New("shutdown timeout")
 
+// shutdown calls the drain functions, waits the drain delay and then
+// calls the shutdown functions, timeout limits the shutdown functions.
 func (d *daemon) shutdown(timeout time.Duration) error {
+	for _, f := range d.df {
+		f()
+	}
+
+	if d.drainDelay > 0 {
+		d.Log.Debugf("daemon is draining for %s", d.drainDelay)
+		time.Sleep(d.drainDelay)
+	}
+
 	timer := time.NewTimer(timeout)
 	wGroup := sync.WaitGroup{}
 
@@ -125,4 +193,24 @@ type WatcherConfig struct {
 	MainKey   string
 	Keys      []string
 	ApplyFunc ApplyConfigFunc
+
+	// ApplyChanges receives the change set of config. It takes precedence
+	// over ApplyFunc.
+	ApplyChanges ApplyChangesFunc
+
+	// Schema describes Keys.
+	Schema Schema
+
+	// Group joins the WatcherConfigs of one connector. Watchers merge
+	// the configs of a group and pass them to ApplyFunc in a single call.
+	Group string
+}
+
+// ChangesFunc returns ApplyChanges or adapted ApplyFunc.
+func (wc WatcherConfig) ChangesFunc() ApplyChangesFunc {
+	if wc.ApplyChanges != nil {
+		return wc.ApplyChanges
+	}
+
+	return wc.ApplyFunc.ChangesFunc()
 }
@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imega/daemon/logging"
+)
+
+func TestDaemon_shutdownDrainsFirst(t *testing.T) {
+	var (
+		mx       sync.Mutex
+		drainAt  time.Time
+		serverAt time.Time
+	)
+
+	d := &daemon{Log: logging.GetNoopLog(), drainDelay: 20 * time.Millisecond}
+
+	d.RegisterDrainFunc(func() {
+		mx.Lock()
+		drainAt = time.Now()
+		mx.Unlock()
+	})
+	d.RegisterShutdownFunc(func() {
+		mx.Lock()
+		serverAt = time.Now()
+		mx.Unlock()
+	})
+
+	if err := d.shutdown(time.Second); err != nil {
+		t.Fatalf("shutdown() error = %s", err)
+	}
+
+	if drainAt.IsZero() || serverAt.Sub(drainAt) < d.drainDelay {
+		t.Errorf("expected the shutdown funcs after the drain delay, drain %s, shutdown %s", drainAt, serverAt)
+	}
+}
+
+type readerFunc func() error
+
+func (f readerFunc) Read() error { return f() }
+
+func TestNew_nilLogger(t *testing.T) {
+	d, err := New(nil, readerFunc(func() error { return nil }), WithDrainDelay(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := false
+	d.RegisterReloadFunc(func() { reloaded = true })
+
+	d.(*daemon).reload()
+
+	if err := d.(*daemon).shutdown(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reloaded {
+		t.Error("reload funcs are not called")
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/logging"
+)
+
+// Flag is a definition of feature flag. The value of key is a JSON object,
+// or short forms: "true", "false" and percentage rollout "25%".
+//
+//	{
+//	    "enabled": true,
+//	    "rollout": 25,
+//	    "by": "x-site-id",
+//	    "variants": {"blue": 50, "green": 50},
+//	    "rules": [{"attribute": "x-site-id", "values": ["1", "2"], "variant": "green"}],
+//	    "default": "red"
+//	}
+type Flag struct {
+	Enabled bool `json:"enabled"`
+
+	// Rollout is a percentage of subjects the flag is enabled for,
+	// 100 by default.
+	Rollout *int `json:"rollout,omitempty"`
+
+	// By is the attribute that identifies a subject of rollout.
+	By string `json:"by,omitempty"`
+
+	// Variants are weights of variants.
+	Variants map[string]int `json:"variants,omitempty"`
+
+	// Rules enable the flag for subjects with attributes,
+	// the first matched rule wins over rollout.
+	Rules []Rule `json:"rules,omitempty"`
+
+	// Default is the variant of disabled flag.
+	Default string `json:"default,omitempty"`
+}
+
+// Rule targets the subjects by attribute.
+type Rule struct {
+	Attribute string   `json:"attribute"`
+	Values    []string `json:"values"`
+	Variant   string   `json:"variant,omitempty"`
+}
+
+// Result is an evaluation of the flag.
+type Result struct {
+	Enabled bool   `json:"enabled"`
+	Variant string `json:"variant,omitempty"`
+}
+
+// VariantOn is a variant of the enabled flag without variants.
+const VariantOn = "on"
+
+const (
+	percent = 100
+	mainKey = "featureflags"
+)
+
+// Flags evaluates feature flags from the snapshot updated
+// by ConfigReader, prefix/featureflags/<name>.
+type Flags struct {
+	log    logging.Logger
+	prefix string
+
+	mx    sync.RWMutex
+	flags map[string]Flag
+
+	daemon.WatcherConfigFunc
+}
+
+// Option .
+type Option func(*Flags)
+
+// WithLogger .
+func WithLogger(l logging.Logger) Option {
+	return func(f *Flags) {
+		f.log = l
+	}
+}
+
+// New returns Flags. The env watcher reads the names only.
+func New(prefix string, names []string, opts ...Option) *Flags {
+	f := &Flags{
+		log:    logging.GetNoopLog(),
+		prefix: prefix,
+		flags:  make(map[string]Flag),
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	f.WatcherConfigFunc = func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:       prefix,
+			MainKey:      mainKey,
+			Keys:         names,
+			ApplyChanges: f.apply,
+		}
+	}
+
+	return f
+}
+
+func (f *Flags) apply(cs daemon.ChangeSet) {
+	base := f.prefix + "/" + mainKey + "/"
+	flags := make(map[string]Flag, len(cs.Conf))
+
+	for k, v := range cs.Conf {
+		if !strings.HasPrefix(k, base) {
+			continue
+		}
+
+		flag, err := Parse(v)
+		if err != nil {
+			f.log.Errorf("failed to parse feature flag %s, %s", k, err)
+
+			continue
+		}
+
+		flags[k[len(base):]] = flag
+	}
+
+	f.mx.Lock()
+	f.flags = flags
+	f.mx.Unlock()
+}
+
+// ErrInvalidFlag is returned if the value is not a flag.
+var ErrInvalidFlag = errors.New("invalid flag")
+
+// Parse parses the value of flag.
+func Parse(value string) (Flag, error) {
+	value = strings.TrimSpace(value)
+
+	if b, err := strconv.ParseBool(value); err == nil {
+		return Flag{Enabled: b}, nil
+	}
+
+	if strings.HasSuffix(value, "%") {
+		p, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+		if err != nil {
+			return Flag{}, fmt.Errorf("%w: %s", ErrInvalidFlag, err)
+		}
+
+		return Flag{Enabled: true, Rollout: &p}, nil
+	}
+
+	flag := Flag{}
+	if err := json.Unmarshal([]byte(value), &flag); err != nil {
+		return Flag{}, fmt.Errorf("%w: %s", ErrInvalidFlag, err)
+	}
+
+	return flag, nil
+}
+
+// Enabled reports whether the flag is enabled in ctx.
+func (f *Flags) Enabled(ctx context.Context, name string) bool {
+	return f.Evaluate(ctx, name).Enabled
+}
+
+// Variant returns the variant of flag in ctx.
+func (f *Flags) Variant(ctx context.Context, name string) string {
+	return f.Evaluate(ctx, name).Variant
+}
+
+// Evaluate returns the evaluation of flag in ctx.
+// An unknown flag is disabled.
+func (f *Flags) Evaluate(ctx context.Context, name string) Result {
+	f.mx.RLock()
+	flag, ok := f.flags[name]
+	f.mx.RUnlock()
+
+	if !ok {
+		return Result{}
+	}
+
+	return flag.Evaluate(ctx, name)
+}
+
+// EvaluateAll returns the evaluations of all flags in ctx.
+func (f *Flags) EvaluateAll(ctx context.Context) map[string]Result {
+	f.mx.RLock()
+	defer f.mx.RUnlock()
+
+	res := make(map[string]Result, len(f.flags))
+
+	for name, flag := range f.flags {
+		res[name] = flag.Evaluate(ctx, name)
+	}
+
+	return res
+}
+
+// Evaluate returns the evaluation of flag with name in ctx.
+func (flag Flag) Evaluate(ctx context.Context, name string) Result {
+	disabled := Result{Variant: flag.Default}
+
+	if !flag.Enabled {
+		return disabled
+	}
+
+	for _, rule := range flag.Rules {
+		value, ok := Attribute(ctx, rule.Attribute)
+		if !ok || !contains(rule.Values, value) {
+			continue
+		}
+
+		if rule.Variant != "" {
+			return Result{Enabled: true, Variant: rule.Variant}
+		}
+
+		return Result{Enabled: true, Variant: flag.variant(ctx, name)}
+	}
+
+	if flag.Rollout != nil && *flag.Rollout < percent {
+		subject, ok := Attribute(ctx, flag.By)
+		if !ok || bucket(name, subject) >= *flag.Rollout {
+			return disabled
+		}
+	}
+
+	return Result{Enabled: true, Variant: flag.variant(ctx, name)}
+}
+
+func (flag Flag) variant(ctx context.Context, name string) string {
+	if len(flag.Variants) == 0 {
+		return VariantOn
+	}
+
+	names := make([]string, 0, len(flag.Variants))
+	total := 0
+
+	for v, w := range flag.Variants {
+		names = append(names, v)
+		total += w
+	}
+
+	sort.Strings(names)
+
+	if total <= 0 {
+		return names[0]
+	}
+
+	subject, _ := Attribute(ctx, flag.By)
+	point := bucket(name+"/variant", subject) * total / percent
+
+	for _, v := range names {
+		point -= flag.Variants[v]
+		if point < 0 {
+			return v
+		}
+	}
+
+	return names[len(names)-1]
+}
+
+func bucket(name, subject string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + subject))
+
+	return int(h.Sum32() % percent)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+type attrsKey struct{}
+
+// WithAttributes returns a context with attributes of subject.
+// The attributes are merged with the attributes of ctx.
+func WithAttributes(ctx context.Context, attrs map[string]string) context.Context {
+	merged := make(map[string]string)
+
+	if parent, ok := ctx.Value(attrsKey{}).(map[string]string); ok {
+		for k, v := range parent {
+			merged[k] = v
+		}
+	}
+
+	for k, v := range attrs {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+// Attribute returns the attribute of subject. The values set
+// by ctxheaders.HeadersToContext are attributes too.
+func Attribute(ctx context.Context, name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+
+	if attrs, ok := ctx.Value(attrsKey{}).(map[string]string); ok {
+		if v, ok := attrs[name]; ok {
+			return v, true
+		}
+	}
+
+	if v, ok := ctx.Value(name).(string); ok && v != "" {
+		return v, true
+	}
+
+	return "", false
+}
+
+// Handler returns an http.Handler
+//
+// It returns the evaluations of all flags in the request context as JSON,
+// or the evaluation of one flag if the query has the parameter name.
+func (f *Flags) Handler() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		var res interface{}
+
+		if name := req.URL.Query().Get("name"); name != "" {
+			res = f.Evaluate(req.Context(), name)
+		} else {
+			res = f.EvaluateAll(req.Context())
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(resp).Encode(res); err != nil {
+			http.Error(resp, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/imega/daemon"
+)
+
+func newFlags(conf map[string]string) *Flags {
+	f := New("my-daemon", nil)
+	f.WatcherConfigFunc().ApplyChanges(daemon.NewChangeSet(conf, nil))
+
+	return f
+}
+
+func TestFlags_Enabled(t *testing.T) {
+	f := newFlags(map[string]string{
+		"my-daemon/featureflags/on":      "true",
+		"my-daemon/featureflags/off":     "false",
+		"my-daemon/featureflags/broken":  "maybe",
+		"my-daemon/featureflags/targets": `{"enabled":true,"rollout":0,"rules":[{"attribute":"x-site-id","values":["7"]}]}`,
+	})
+
+	ctx := context.Background()
+
+	if !f.Enabled(ctx, "on") {
+		t.Errorf("Enabled(on) = false, want true")
+	}
+
+	if f.Enabled(ctx, "off") || f.Enabled(ctx, "broken") || f.Enabled(ctx, "unknown") {
+		t.Errorf("Enabled() = true, want false")
+	}
+
+	if f.Enabled(ctx, "targets") {
+		t.Errorf("Enabled(targets) without attributes = true, want false")
+	}
+
+	site := context.WithValue(ctx, "x-site-id", "7") // nolint:golint,staticcheck
+	if !f.Enabled(site, "targets") {
+		t.Errorf("Enabled(targets) with ctxheaders value = false, want true")
+	}
+
+	if !f.Enabled(WithAttributes(ctx, map[string]string{"x-site-id": "7"}), "targets") {
+		t.Errorf("Enabled(targets) with attributes = false, want true")
+	}
+}
+
+func TestFlags_Rollout(t *testing.T) {
+	f := newFlags(map[string]string{
+		"my-daemon/featureflags/half": `{"enabled":true,"rollout":50,"by":"user"}`,
+	})
+
+	enabled := 0
+
+	for i := 0; i < 1000; i++ {
+		ctx := WithAttributes(context.Background(), map[string]string{"user": strconv.Itoa(i)})
+		if f.Enabled(ctx, "half") {
+			enabled++
+		}
+
+		if f.Enabled(ctx, "half") != f.Enabled(ctx, "half") {
+			t.Fatalf("Enabled() is not stable for the subject")
+		}
+	}
+
+	if enabled < 400 || enabled > 600 {
+		t.Errorf("Enabled() for %d of 1000 subjects, want about 500", enabled)
+	}
+}
+
+func TestFlags_Variant(t *testing.T) {
+	f := newFlags(map[string]string{
+		"my-daemon/featureflags/color": `{
+			"enabled": true,
+			"by": "user",
+			"variants": {"blue": 1, "green": 1},
+			"rules": [{"attribute": "x-site-id", "values": ["7"], "variant": "red"}],
+			"default": "white"
+		}`,
+		"my-daemon/featureflags/plain": "true",
+	})
+
+	seen := map[string]int{}
+
+	for i := 0; i < 100; i++ {
+		ctx := WithAttributes(context.Background(), map[string]string{"user": strconv.Itoa(i)})
+		seen[f.Variant(ctx, "color")]++
+	}
+
+	if len(seen) != 2 || seen["blue"] == 0 || seen["green"] == 0 {
+		t.Errorf("Variant() = %v, want blue and green", seen)
+	}
+
+	ctx := WithAttributes(context.Background(), map[string]string{"x-site-id": "7"})
+	if v := f.Variant(ctx, "color"); v != "red" {
+		t.Errorf("Variant() = %s, want red", v)
+	}
+
+	if v := f.Variant(ctx, "plain"); v != VariantOn {
+		t.Errorf("Variant() = %s, want %s", v, VariantOn)
+	}
+}
+
+func TestFlags_Handler(t *testing.T) {
+	f := newFlags(map[string]string{
+		"my-daemon/featureflags/on":  "true",
+		"my-daemon/featureflags/off": "false",
+	})
+
+	ht := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	f.Handler().ServeHTTP(ht, req)
+
+	actual := map[string]Result{}
+	if err := json.NewDecoder(ht.Body).Decode(&actual); err != nil {
+		t.Fatalf("failed to decode response, %s", err)
+	}
+
+	want := map[string]Result{
+		"on":  {Enabled: true, Variant: VariantOn},
+		"off": {},
+	}
+
+	if len(actual) != len(want) || actual["on"] != want["on"] || actual["off"] != want["off"] {
+		t.Errorf("Handler() = %v, want %v", actual, want)
+	}
+}
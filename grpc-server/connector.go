@@ -7,11 +7,11 @@ import (
 	"runtime/debug"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
-	grpc_logrus "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	"github.com/imega/daemon"
-	"github.com/sirupsen/logrus"
+	"github.com/imega/daemon/logging"
+	"github.com/imega/daemon/logging/requestlog"
 	"google.golang.org/grpc"
 )
 
@@ -19,9 +19,10 @@ import (
 type Connector struct {
 	prefixClient string
 
-	log   logrus.FieldLogger
+	log   logging.Logger
 	opts  *optionsServer
 	gOpts []grpc.ServerOption
+	rOpts []requestlog.Option
 
 	srv *grpc.Server
 	rs  RegisterServices
@@ -37,6 +38,7 @@ type optionsServer struct {
 
 const (
 	configGRPCHost = "/grpc/host"
+	defaultHost    = "0.0.0.0:65535"
 )
 
 // RegisterServices will register any services.
@@ -46,12 +48,14 @@ type RegisterServices func(srv *grpc.Server)
 type Option func(*Connector)
 
 // WithLogger .
-func WithLogger(log logrus.FieldLogger) Option {
+func WithLogger(log logging.Logger) Option {
 	return func(o *Connector) {
-		if e, ok := log.(*logrus.Entry); ok {
-			newVerbosityLogger(e)
+		if log == nil {
+			return
 		}
 
+		newVerbosityLogger(log)
+
 		o.log = log
 	}
 }
@@ -70,13 +74,22 @@ func WithServerOptions(opts ...grpc.ServerOption) Option {
 	}
 }
 
+// WithRequestLogOptions configures the request-scoped logger,
+// the handlers get it by logging.GetLogger.
+func WithRequestLogOptions(opts ...requestlog.Option) Option {
+	return func(o *Connector) {
+		o.rOpts = opts
+	}
+}
+
 // New get a instance of grpc.
 func New(prefix string, opts ...Option) *Connector {
 	conn := &Connector{
 		prefixClient: prefix,
+		log:          logging.GetNoopLog(),
 
 		opts: &optionsServer{
-			Host: "0.0.0.0:65535",
+			Host: defaultHost,
 		},
 	}
 
@@ -90,7 +103,8 @@ func New(prefix string, opts ...Option) *Connector {
 		return daemon.WatcherConfig{
 			Prefix:    prefix,
 			MainKey:   "grpc",
-			Keys:      []string{"host"},
+			Keys:      schema().Names(),
+			Schema:    schema(),
 			ApplyFunc: conn.connect,
 		}
 	}
@@ -102,14 +116,20 @@ func New(prefix string, opts ...Option) *Connector {
 	return conn
 }
 
+func schema() daemon.Schema {
+	return daemon.Schema{
+		{
+			Name:        "host",
+			Type:        daemon.TypeString,
+			Default:     defaultHost,
+			Description: "Address to listen on, host:port.",
+		},
+	}
+}
+
 var errRecovery = errors.New("recovery handler error")
 
 func (s *Connector) newServer() *grpc.Server {
-	var log *logrus.Entry
-	if e, ok := s.log.(*logrus.Entry); ok {
-		log = e
-	}
-
 	rOpts := []grpc_recovery.Option{
 		grpc_recovery.WithRecoveryHandler(func(p interface{}) (err error) {
 			stack := string(debug.Stack())
@@ -118,23 +138,15 @@ func (s *Connector) newServer() *grpc.Server {
 		}),
 	}
 
-	loggerOpts := []grpc_logrus.Option{
-		grpc_logrus.WithDecider(func(methodFullName string, err error) bool {
-			if err == nil && methodFullName == "/grpc.health.v1.Health/Check" {
-				return false
-			}
-
-			return true
-		}),
-	}
-
 	opts := []grpc.ServerOption{
 		grpc_middleware.WithUnaryServerChain(
 			grpc_ctxtags.UnaryServerInterceptor(),
-			grpc_logrus.UnaryServerInterceptor(log, loggerOpts...),
+			requestlog.UnaryServerInterceptor(s.requestLogOptions()...),
+			unaryLoggingInterceptor(),
 			grpc_recovery.UnaryServerInterceptor(rOpts...),
 		),
 		grpc_middleware.WithStreamServerChain(
+			requestlog.StreamServerInterceptor(s.requestLogOptions()...),
 			grpc_recovery.StreamServerInterceptor(rOpts...),
 		),
 	}
@@ -142,12 +154,16 @@ func (s *Connector) newServer() *grpc.Server {
 	return grpc.NewServer(append(opts, s.gOpts...)...)
 }
 
+func (s *Connector) requestLogOptions() []requestlog.Option {
+	return append([]requestlog.Option{requestlog.WithLogger(s.log)}, s.rOpts...)
+}
+
 func (s *Connector) connect(conf, last map[string]string) {
 	reset := s.reset(last)
 	config := s.config(conf)
 
 	if !reset && !config {
-		s.log.Debug("grpc connector has same configuration")
+		s.log.Debugf("grpc connector has same configuration")
 
 		return
 	}
@@ -155,17 +171,16 @@ func (s *Connector) connect(conf, last map[string]string) {
 	if s.lis != nil {
 		s.log.Debugf("grpc connector start graceful stop, %s", s.lis.Addr().String())
 		s.srv.GracefulStop()
-		s.log.Debug("grpc connector end graceful stop")
+		s.log.Debugf("grpc connector end graceful stop")
 
 		s.srv = s.newServer()
 	}
 
 	listener, err := net.Listen("tcp", s.opts.Host)
 	if err != nil {
-		s.log.Errorf(
-			"failed to listen on the TCP network address %s, %s",
+		s.log.WithError(err).Errorf(
+			"failed to listen on the TCP network address %s",
 			s.opts.Host,
-			err,
 		)
 
 		return
@@ -182,7 +197,7 @@ func (s *Connector) connect(conf, last map[string]string) {
 
 		if err := s.srv.Serve(listener); err != nil {
 			if !errors.Is(err, grpc.ErrServerStopped) {
-				s.log.Error(err)
+				s.log.WithError(err).Errorf("failed to serve grpc")
 			}
 		}
 	}()
@@ -207,7 +222,7 @@ func (s *Connector) reset(last map[string]string) bool {
 	for k := range last {
 		if k == s.prefixClient+configGRPCHost {
 			needUpdate = true
-			s.opts.Host = "0.0.0.0:65535"
+			s.opts.Host = defaultHost
 		}
 	}
 
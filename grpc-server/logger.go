@@ -1,34 +1,158 @@
 package grpcserver
 
 import (
-	"strings"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
 
-	"github.com/sirupsen/logrus"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"github.com/imega/daemon/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/status"
 )
 
-const verbosityLevel = 99
+const healthCheckMethod = "/grpc.health.v1.Health/Check"
 
-func newVerbosityLogger(log *logrus.Entry) {
-	if log == nil || log.Logger.Level != logrus.DebugLevel {
+// newVerbosityLogger routes the internal logs of grpc to log if
+// the debug level is enabled, info messages are logged at debug level.
+func newVerbosityLogger(log logging.Logger) {
+	if !logging.Enabled(log, logging.LevelDebug) {
 		return
 	}
 
-	vlog := &verbosityFormatLogger{log: log}
+	grpclog.SetLoggerV2(&verbosityLogger{log: log})
+}
+
+type verbosityLogger struct {
+	log logging.Logger
+}
+
+func (l *verbosityLogger) Info(args ...interface{}) {
+	l.log.Debugf("%s", fmt.Sprint(args...))
+}
 
-	grpclog.SetLoggerV2(
-		grpclog.NewLoggerV2WithVerbosity(vlog, vlog, vlog, verbosityLevel),
-	)
+func (l *verbosityLogger) Infoln(args ...interface{}) {
+	l.log.Debugf("%s", fmt.Sprint(args...))
 }
 
-type verbosityFormatLogger struct {
-	log *logrus.Entry
+func (l *verbosityLogger) Infof(format string, args ...interface{}) {
+	l.log.Debugf(format, args...)
 }
 
-func (l *verbosityFormatLogger) Write(p []byte) (n int, err error) {
-	s := strings.TrimRight(string(p), "\n")
-	s = strings.ReplaceAll(s, `"`, "")
-	l.log.Debug(s)
+func (l *verbosityLogger) Warning(args ...interface{}) {
+	l.log.Warnf("%s", fmt.Sprint(args...))
+}
+
+func (l *verbosityLogger) Warningln(args ...interface{}) {
+	l.log.Warnf("%s", fmt.Sprint(args...))
+}
+
+func (l *verbosityLogger) Warningf(format string, args ...interface{}) {
+	l.log.Warnf(format, args...)
+}
 
-	return len(p), nil
+func (l *verbosityLogger) Error(args ...interface{}) {
+	l.log.Errorf("%s", fmt.Sprint(args...))
+}
+
+func (l *verbosityLogger) Errorln(args ...interface{}) {
+	l.log.Errorf("%s", fmt.Sprint(args...))
+}
+
+func (l *verbosityLogger) Errorf(format string, args ...interface{}) {
+	l.log.Errorf(format, args...)
+}
+
+func (l *verbosityLogger) Fatal(args ...interface{}) {
+	l.log.Errorf("%s", fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+func (l *verbosityLogger) Fatalln(args ...interface{}) {
+	l.log.Errorf("%s", fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+func (l *verbosityLogger) Fatalf(format string, args ...interface{}) {
+	l.log.Errorf(format, args...)
+	os.Exit(1)
+}
+
+func (l *verbosityLogger) V(int) bool {
+	return logging.Enabled(l.log, logging.LevelDebug)
+}
+
+// unaryLoggingInterceptor logs the result of unary calls by the
+// request-scoped logger, successful health checks are skipped.
+// The handler gets the logger of the call by logging.GetLogger.
+func unaryLoggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		service := path.Dir(info.FullMethod)[1:]
+		method := path.Base(info.FullMethod)
+
+		ctx = logging.Enrich(ctx, map[string]interface{}{
+			"system":       "grpc",
+			"span.kind":    "server",
+			"grpc.service": service,
+			"grpc.method":  method,
+		})
+
+		resp, err := handler(ctx, req)
+
+		if err == nil && info.FullMethod == healthCheckMethod {
+			return resp, err
+		}
+
+		code := status.Code(err)
+		fields := map[string]interface{}{
+			"grpc.code":       code.String(),
+			"grpc.start_time": start.Format(time.RFC3339),
+			"grpc.time_ms":    float32(time.Since(start).Nanoseconds()/1000) / 1000,
+		}
+
+		for k, v := range grpc_ctxtags.Extract(ctx).Values() {
+			fields[k] = v
+		}
+
+		callLog := logging.GetLogger(ctx).WithFields(fields)
+		if err != nil {
+			callLog = callLog.WithError(err)
+		}
+
+		msg := "finished unary call with code " + code.String()
+
+		switch codeToLevel(code) {
+		case logging.LevelError:
+			callLog.Errorf("%s", msg)
+		case logging.LevelWarn:
+			callLog.Warnf("%s", msg)
+		default:
+			callLog.Infof("%s", msg)
+		}
+
+		return resp, err
+	}
+}
+
+func codeToLevel(code codes.Code) string {
+	switch code {
+	case codes.OK, codes.Canceled, codes.InvalidArgument, codes.NotFound,
+		codes.AlreadyExists, codes.Unauthenticated:
+		return logging.LevelInfo
+	case codes.DeadlineExceeded, codes.PermissionDenied, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange, codes.Unavailable:
+		return logging.LevelWarn
+	default:
+		return logging.LevelError
+	}
 }
@@ -0,0 +1,392 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/imega/daemon"
+)
+
+const (
+	defaultInterval         = 10 * time.Second
+	defaultCheckTimeout     = 5 * time.Second
+	defaultFailureThreshold = 3
+	defaultSuccessThreshold = 1
+)
+
+var (
+	// ErrNotEvaluated is the error of checks before their first evaluation.
+	ErrNotEvaluated = errors.New("not evaluated yet")
+
+	// ErrStillRunning is the error of check which is still running
+	// after its timeout, it is not started again until it returns.
+	ErrStillRunning = errors.New("previous check is still running")
+
+	// ErrDuplicateCheck is returned by NewEvaluator if checks
+	// have the same name.
+	ErrDuplicateCheck = errors.New("duplicate check")
+)
+
+// Evaluator runs checks on a schedule and caches their results, so the
+// probes don't run the checks. A check goes down after FailureThreshold
+// consecutive failures and up after SuccessThreshold consecutive
+// successes, the first result is taken as is.
+//
+// Its ShutdownFunc stops the evaluation.
+type Evaluator struct {
+	checks           []Check
+	interval         time.Duration
+	timeout          time.Duration
+	failureThreshold int
+	successThreshold int
+
+	checker  *Checker
+	observer func(Report)
+
+	mx     sync.RWMutex
+	states map[string]*checkState
+	subs   map[int]func()
+	nextID int
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+
+	daemon.ShutdownFunc
+}
+
+type checkState struct {
+	result    Result
+	evaluated bool
+	running   bool
+	failures  int
+	successes int
+}
+
+// EvaluatorOption .
+type EvaluatorOption func(*Evaluator)
+
+// WithInterval sets the period of evaluation, 10s by default.
+func WithInterval(d time.Duration) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.interval = d
+	}
+}
+
+// WithCheckTimeout sets the timeout of every check, 5s by default.
+func WithCheckTimeout(d time.Duration) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.timeout = d
+	}
+}
+
+// WithThresholds sets the number of consecutive failures before a check
+// goes down and of consecutive successes before it goes up, 3 and 1
+// by default.
+func WithThresholds(failure, success int) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.failureThreshold = failure
+		e.successThreshold = success
+	}
+}
+
+// WithObserver calls fn with the report after every evaluation,
+// e.g. to export metrics.
+func WithObserver(fn func(Report)) EvaluatorOption {
+	return func(e *Evaluator) {
+		e.observer = fn
+	}
+}
+
+// NewEvaluator get a instance of Evaluator, call Start to run checks.
+// The names of checks must be unique.
+func NewEvaluator(checks []Check, opts ...EvaluatorOption) (*Evaluator, error) {
+	e := &Evaluator{
+		checks:           checks,
+		interval:         defaultInterval,
+		timeout:          defaultCheckTimeout,
+		failureThreshold: defaultFailureThreshold,
+		successThreshold: defaultSuccessThreshold,
+		checker:          NewChecker(),
+		states:           make(map[string]*checkState),
+		subs:             make(map[int]func()),
+		stop:             make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	for _, c := range checks {
+		if _, ok := e.states[c.Name]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateCheck, c.Name)
+		}
+
+		e.states[c.Name] = &checkState{
+			result: Result{Name: c.Name, Status: c.failed(), Error: ErrNotEvaluated.Error()},
+		}
+	}
+
+	e.ShutdownFunc = e.Stop
+
+	return e, nil
+}
+
+// Checks returns the checks.
+func (e *Evaluator) Checks() []Check {
+	return e.checks
+}
+
+// Start evaluates the checks once and then on schedule until Stop.
+func (e *Evaluator) Start() {
+	e.startOnce.Do(func() {
+		e.Evaluate()
+
+		go e.loop()
+	})
+}
+
+// Stop stops the evaluation.
+func (e *Evaluator) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stop)
+	})
+}
+
+func (e *Evaluator) loop() {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.Evaluate()
+		}
+	}
+}
+
+// Evaluate runs all checks concurrently and updates the cache.
+// A check which is still running after its timeout fails with
+// ErrStillRunning until it returns.
+func (e *Evaluator) Evaluate() {
+	results := make([]Result, len(e.checks))
+	wg := sync.WaitGroup{}
+
+	for i, c := range e.checks {
+		if !e.acquire(c.Name) {
+			results[i] = Result{
+				Name:        c.Name,
+				Status:      c.failed(),
+				Error:       ErrStillRunning.Error(),
+				LastSuccess: e.checker.last(c.Name),
+			}
+
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int, c Check) {
+			defer wg.Done()
+
+			results[i] = e.run(c)
+		}(i, c)
+	}
+
+	wg.Wait()
+
+	changed := false
+
+	e.mx.Lock()
+
+	for i, c := range e.checks {
+		failure, success := e.thresholds(c)
+		changed = e.states[c.Name].apply(results[i], failure, success) || changed
+	}
+
+	subs := make([]func(), 0, len(e.subs))
+	for _, fn := range e.subs {
+		subs = append(subs, fn)
+	}
+
+	e.mx.Unlock()
+
+	if e.observer != nil {
+		e.observer(e.Report())
+	}
+
+	if !changed {
+		return
+	}
+
+	for _, fn := range subs {
+		fn()
+	}
+}
+
+// run runs the check until it returns or its timeout expires. The check
+// is released when it returns, the checks which ignore ctx may
+// return later than the timeout.
+func (e *Evaluator) run(c Check) Result {
+	timeout := e.timeout
+	if c.Timeout > 0 {
+		timeout = c.Timeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+
+	go func() {
+		res := e.checker.run(ctx, c)
+		e.release(c.Name)
+		done <- res
+	}()
+
+	select {
+	case res := <-done:
+		return res
+	case <-ctx.Done():
+		return Result{
+			Name:        c.Name,
+			Status:      c.failed(),
+			Duration:    time.Since(start),
+			Error:       ctx.Err().Error(),
+			LastSuccess: e.checker.last(c.Name),
+		}
+	}
+}
+
+func (e *Evaluator) acquire(name string) bool {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	s := e.states[name]
+	if s.running {
+		return false
+	}
+
+	s.running = true
+
+	return true
+}
+
+func (e *Evaluator) release(name string) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	e.states[name].running = false
+}
+
+func (e *Evaluator) thresholds(c Check) (int, int) {
+	failure, success := e.failureThreshold, e.successThreshold
+
+	if c.FailureThreshold > 0 {
+		failure = c.FailureThreshold
+	}
+
+	if c.SuccessThreshold > 0 {
+		success = c.SuccessThreshold
+	}
+
+	return failure, success
+}
+
+// apply updates the state by the result, it reports whether
+// the status has changed.
+func (s *checkState) apply(res Result, failure, success int) bool {
+	prev := s.result.Status
+
+	if res.Status == StatusUp {
+		s.successes++
+		s.failures = 0
+	} else {
+		s.failures++
+		s.successes = 0
+	}
+
+	st := prev
+
+	switch {
+	case !s.evaluated:
+		st = res.Status
+	case prev != StatusUp && res.Status == StatusUp && s.successes >= success:
+		st = StatusUp
+	case prev == StatusUp && res.Status != StatusUp && s.failures >= failure:
+		st = res.Status
+	}
+
+	res.Status = st
+	s.result = res
+	s.evaluated = true
+
+	return st != prev
+}
+
+// Report returns the cached results.
+func (e *Evaluator) Report() Report {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+
+	results := make([]Result, 0, len(e.checks))
+	for _, c := range e.checks {
+		results = append(results, e.states[c.Name].result)
+	}
+
+	return NewReport(results)
+}
+
+// Subscribe calls fn when the status of a check has changed.
+// It returns a function to unsubscribe.
+func (e *Evaluator) Subscribe(fn func()) func() {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	id := e.nextID
+	e.nextID++
+	e.subs[id] = fn
+
+	return func() {
+		e.mx.Lock()
+		defer e.mx.Unlock()
+
+		delete(e.subs, id)
+	}
+}
+
+// ReportOf returns the cached results of the named checks,
+// the unknown names are skipped.
+func (e *Evaluator) ReportOf(names ...string) Report {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+
+	results := make([]Result, 0, len(names))
+	for _, name := range names {
+		if s, ok := e.states[name]; ok {
+			results = append(results, s.result)
+		}
+	}
+
+	return NewReport(results)
+}
@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEvaluator_Thresholds(t *testing.T) {
+	var (
+		healthy int32 = 1
+		calls   int32
+	)
+
+	e, err := NewEvaluator([]Check{
+		{Name: "mysql", Func: func(context.Context) error {
+			atomic.AddInt32(&calls, 1)
+
+			if atomic.LoadInt32(&healthy) == 0 {
+				return errors.New("connection refused")
+			}
+
+			return nil
+		}},
+	}, WithInterval(time.Hour), WithThresholds(2, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := e.Report(); r.Healthy() || r.Checks[0].Error != ErrNotEvaluated.Error() {
+		t.Fatalf("unexpected report before evaluation %+v", r)
+	}
+
+	changes := int32(0)
+	unsubscribe := e.Subscribe(func() { atomic.AddInt32(&changes, 1) })
+
+	defer unsubscribe()
+
+	e.Start()
+	defer e.Stop()
+
+	steps := []struct {
+		healthy int32
+		want    Status
+	}{
+		{healthy: 1, want: StatusUp},
+		{healthy: 0, want: StatusUp},
+		{healthy: 1, want: StatusUp},
+		{healthy: 0, want: StatusUp},
+		{healthy: 0, want: StatusDown},
+		{healthy: 1, want: StatusDown},
+		{healthy: 1, want: StatusUp},
+	}
+
+	for i, step := range steps {
+		atomic.StoreInt32(&healthy, step.healthy)
+
+		if i > 0 {
+			e.Evaluate()
+		}
+
+		r := e.Report()
+		if r.Status != step.want {
+			t.Fatalf("step %d: expected %s, got %+v", i, step.want, r)
+		}
+
+		if step.healthy == 0 && r.Checks[0].Error != "connection refused" {
+			t.Errorf("step %d: expected the last error, got %+v", i, r.Checks[0])
+		}
+	}
+
+	if c := atomic.LoadInt32(&calls); c != int32(len(steps)) {
+		t.Errorf("expected %d calls, got %d", len(steps), c)
+	}
+
+	if c := atomic.LoadInt32(&changes); c != 3 {
+		t.Errorf("expected 3 changes, got %d", c)
+	}
+}
+
+func TestEvaluator_Timeout(t *testing.T) {
+	e, err := NewEvaluator([]Check{
+		{Name: "slow", Timeout: 20 * time.Millisecond, Func: func(ctx context.Context) error {
+			<-ctx.Done()
+
+			return ctx.Err()
+		}},
+		{Name: "fast", Func: func(context.Context) error { return nil }},
+	}, WithCheckTimeout(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Evaluate()
+
+	r := e.Report()
+	if r.Healthy() || r.Checks[0].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("unexpected report %+v", r)
+	}
+
+	if r := e.ReportOf("fast", "unknown"); !r.Healthy() || len(r.Checks) != 1 {
+		t.Fatalf("unexpected report %+v", r)
+	}
+}
+
+func TestEvaluator_StillRunning(t *testing.T) {
+	var calls int32
+
+	release := make(chan struct{})
+
+	e, err := NewEvaluator([]Check{
+		FromFunc("mysql", func() bool {
+			atomic.AddInt32(&calls, 1)
+			<-release
+
+			return true
+		}),
+	}, WithCheckTimeout(20*time.Millisecond), WithThresholds(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Evaluate()
+
+	if r := e.Report(); r.Healthy() || r.Checks[0].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("unexpected report %+v", r)
+	}
+
+	e.Evaluate()
+
+	if r := e.Report(); r.Healthy() || r.Checks[0].Error != ErrStillRunning.Error() {
+		t.Fatalf("unexpected report %+v", r)
+	}
+
+	if c := atomic.LoadInt32(&calls); c != 1 {
+		t.Errorf("expected 1 call while the check is running, got %d", c)
+	}
+
+	close(release)
+
+	for i := 0; i < 100 && !e.Report().Healthy(); i++ {
+		e.Evaluate()
+		time.Sleep(time.Millisecond)
+	}
+
+	if r := e.Report(); !r.Healthy() {
+		t.Fatalf("unexpected report after the check has returned %+v", r)
+	}
+}
+
+func TestNewEvaluator_DuplicateCheck(t *testing.T) {
+	_, err := NewEvaluator([]Check{
+		FromFunc("mysql", func() bool { return true }),
+		FromFunc("mysql", func() bool { return false }),
+	})
+	if !errors.Is(err, ErrDuplicateCheck) {
+		t.Fatalf("expected ErrDuplicateCheck, got %v", err)
+	}
+}
@@ -15,43 +15,357 @@
 package health
 
 import (
+	"sync"
+	"time"
+
 	"github.com/imega/daemon"
+	healthcheck "github.com/imega/daemon/health"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
+const defaultInterval = 5 * time.Second
+
 // New registers a health-check server and its implementation
 // to the gRPC server. This must be called before invoking Serve.
 func New(s reflection.GRPCServer, f ...daemon.HealthCheckFunc) {
-	grpc_health_v1.RegisterHealthServer(s, &server{f})
+	Register(s, WithHealthCheckFuncs(f...))
+}
+
+// Register registers a health-check server with options, see New.
+// It returns the function which sets all services NOT_SERVING for
+// watchers, register it by Daemon.RegisterDrainFunc.
+func Register(s reflection.GRPCServer, opts ...Option) daemon.ShutdownFunc {
+	srv := &server{}
+
+	for _, opt := range opts {
+		opt(srv)
+	}
+
+	grpc_health_v1.RegisterHealthServer(s, srv)
 	reflection.Register(s)
+
+	return srv.shutdown
+}
+
+// Option .
+type Option func(*server)
+
+// WithHealthCheckFuncs adds the checks of the overall "" service.
+func WithHealthCheckFuncs(f ...daemon.HealthCheckFunc) Option {
+	return func(s *server) {
+		s.fn = append(s.fn, f...)
+	}
+}
+
+// WithService adds the checks of gRPC service, e.g. "my.v1.Service".
+// The overall "" service is serving if the checks of all services pass.
+func WithService(name string, checks ...healthcheck.Check) Option {
+	return func(s *server) {
+		if s.services == nil {
+			s.services = make(map[string][]healthcheck.Check)
+		}
+
+		s.services[name] = append(s.services[name], checks...)
+	}
+}
+
+// WithEvaluator serves the cached results of evaluator instead of
+// running the checks, the status of service is made of the results
+// of its checks by name, the HealthCheckFuncs are ignored.
+// Watch sends the transitions of evaluator. The evaluator must be started.
+func WithEvaluator(e *healthcheck.Evaluator) Option {
+	return func(s *server) {
+		s.evaluator = e
+	}
+}
+
+// WithInterval sets the period of evaluation of checks for Watch,
+// the checks are evaluated while there are watchers. 5s by default.
+func WithInterval(d time.Duration) Option {
+	return func(s *server) {
+		s.interval = d
+	}
 }
 
+type servingStatus = grpc_health_v1.HealthCheckResponse_ServingStatus
+
 type server struct {
-	fn []daemon.HealthCheckFunc
+	fn       []daemon.HealthCheckFunc
+	services map[string][]healthcheck.Check
+	interval time.Duration
+
+	evaluator *healthcheck.Evaluator
+
+	mx       sync.Mutex
+	checkers map[string]*healthcheck.Checker
+	watchers map[string]map[chan servingStatus]struct{}
+	statuses map[string]servingStatus
+	stopped  bool
+	stop     chan struct{}
 }
 
-func (s *server) Check(
-	context.Context,
-	*grpc_health_v1.HealthCheckRequest,
-) (*grpc_health_v1.HealthCheckResponse, error) {
-	status := grpc_health_v1.HealthCheckResponse_SERVING
+// checker returns the checker of service, false if service is unknown.
+func (s *server) checker(service string) (*healthcheck.Checker, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if c, ok := s.checkers[service]; ok {
+		return c, true
+	}
 
-	for _, p := range s.fn {
-		if ok := p(); !ok {
-			status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	var checks []healthcheck.Check
 
-			break
+	if service == "" {
+		checks = healthcheck.FromFuncs(s.fn...)
+		for _, sc := range s.services {
+			checks = append(checks, sc...)
 		}
+	} else {
+		sc, ok := s.services[service]
+		if !ok {
+			return nil, false
+		}
+
+		checks = sc
+	}
+
+	if s.checkers == nil {
+		s.checkers = make(map[string]*healthcheck.Checker)
 	}
 
-	return &grpc_health_v1.HealthCheckResponse{Status: status}, nil
+	c := healthcheck.NewChecker(checks...)
+	s.checkers[service] = c
+
+	return c, true
 }
 
+func (s *server) evaluate(ctx context.Context, service string) servingStatus {
+	c, ok := s.checker(service)
+	if !ok {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+
+	s.mx.Lock()
+	stopped := s.stopped
+	s.mx.Unlock()
+
+	if stopped {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+
+	var report healthcheck.Report
+
+	switch {
+	case s.evaluator == nil:
+		report = c.Run(ctx)
+	case service == "":
+		report = s.evaluator.Report()
+	default:
+		names := make([]string, 0, len(c.Checks()))
+		for _, check := range c.Checks() {
+			names = append(names, check.Name)
+		}
+
+		report = s.evaluator.ReportOf(names...)
+	}
+
+	// degraded is serving, see the detailed report of health/http.
+	if report.Healthy() {
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	}
+
+	return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+}
+
+func (s *server) Check(
+	ctx context.Context,
+	req *grpc_health_v1.HealthCheckRequest,
+) (*grpc_health_v1.HealthCheckResponse, error) {
+	st := s.evaluate(ctx, req.GetService())
+	if st == grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+
+	return &grpc_health_v1.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch sends the status of service and its transitions until
+// the client cancels the stream.
 func (s *server) Watch(
-	*grpc_health_v1.HealthCheckRequest,
-	grpc_health_v1.Health_WatchServer,
+	req *grpc_health_v1.HealthCheckRequest,
+	stream grpc_health_v1.Health_WatchServer,
 ) error {
-	return nil
+	service := req.GetService()
+	ch := s.subscribe(stream.Context(), service)
+
+	defer s.unsubscribe(service, ch)
+
+	for {
+		select {
+		case st := <-ch:
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: st}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		}
+	}
+}
+
+func (s *server) subscribe(ctx context.Context, service string) chan servingStatus {
+	ch := make(chan servingStatus, 1)
+
+	s.mx.Lock()
+
+	if s.watchers == nil {
+		s.watchers = make(map[string]map[chan servingStatus]struct{})
+		s.statuses = make(map[string]servingStatus)
+	}
+
+	if s.watchers[service] == nil {
+		s.watchers[service] = make(map[chan servingStatus]struct{})
+	}
+
+	s.watchers[service][ch] = struct{}{}
+
+	st, known := s.statuses[service]
+	stopped := s.stopped
+
+	if s.stop == nil && !stopped {
+		s.stop = make(chan struct{})
+		go s.loop(s.stop)
+	}
+
+	switch {
+	case stopped:
+		send(ch, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	case known:
+		send(ch, st)
+	}
+
+	s.mx.Unlock()
+
+	if !stopped && !known {
+		s.update(service, s.evaluate(ctx, service), true)
+	}
+
+	return ch
+}
+
+// send replaces the pending status of ch by st,
+// a slow watcher gets the latest status only.
+func send(ch chan servingStatus, st servingStatus) {
+	select {
+	case <-ch:
+	default:
+	}
+
+	ch <- st
+}
+
+func (s *server) unsubscribe(service string, ch chan servingStatus) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	delete(s.watchers[service], ch)
+
+	if len(s.watchers[service]) == 0 {
+		delete(s.watchers, service)
+		delete(s.statuses, service)
+	}
+
+	if len(s.watchers) == 0 && s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// update sends st to the watchers of service if it has changed.
+func (s *server) update(service string, st servingStatus, force bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if last, ok := s.statuses[service]; ok && last == st && !force {
+		return
+	}
+
+	if s.stopped && st != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		return
+	}
+
+	if _, ok := s.watchers[service]; !ok {
+		return
+	}
+
+	s.statuses[service] = st
+
+	for ch := range s.watchers[service] {
+		send(ch, st)
+	}
+}
+
+func (s *server) loop(stop chan struct{}) {
+	interval := s.interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	tick := ticker.C
+
+	if s.evaluator != nil {
+		changed := make(chan time.Time, 1)
+		unsubscribe := s.evaluator.Subscribe(func() {
+			select {
+			case changed <- time.Now():
+			default:
+			}
+		})
+
+		defer unsubscribe()
+
+		tick = changed
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick:
+			s.mx.Lock()
+			services := make([]string, 0, len(s.watchers))
+			for service := range s.watchers {
+				services = append(services, service)
+			}
+			s.mx.Unlock()
+
+			for _, service := range services {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				s.update(service, s.evaluate(ctx, service), false)
+				cancel()
+			}
+		}
+	}
+}
+
+// shutdown sets all services NOT_SERVING for Check and watchers.
+func (s *server) shutdown() {
+	s.mx.Lock()
+	s.stopped = true
+
+	services := make([]string, 0, len(s.watchers))
+	for service := range s.watchers {
+		services = append(services, service)
+	}
+	s.mx.Unlock()
+
+	for _, service := range services {
+		s.update(service, grpc_health_v1.HealthCheckResponse_NOT_SERVING, false)
+	}
 }
@@ -1,11 +1,18 @@
 package health
 
 import (
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/imega/daemon"
+	healthcheck "github.com/imega/daemon/health"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 func TestRegisterResourceHealthCheck_RegistersHealthCheck(t *testing.T) {
@@ -73,3 +80,235 @@ func TestCheck_AnyCheckFailed_ReportsStatusNOT_SERVING(t *testing.T) {
 		t.Fatalf("health check expected to report NOT_SERVING status")
 	}
 }
+
+func TestCheck_Service(t *testing.T) {
+	s := &server{}
+	WithService("my.v1.Service", healthcheck.Check{
+		Name: "mysql",
+		Func: func(context.Context) error { return errors.New("connection refused") },
+	})(s)
+
+	resp, err := s.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "my.v1.Service"})
+	if err != nil || resp.GetStatus() != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("health check expected to report NOT_SERVING status, %v", err)
+	}
+
+	resp, _ = s.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if resp.GetStatus() != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("overall health check expected to report NOT_SERVING status")
+	}
+
+	_, err = s.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("health check expected to return NotFound, got %v", err)
+	}
+}
+
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan grpc_health_v1.HealthCheckResponse_ServingStatus
+}
+
+func (w *watchStream) Context() context.Context { return w.ctx }
+
+func (w *watchStream) Send(resp *grpc_health_v1.HealthCheckResponse) error {
+	w.sent <- resp.GetStatus()
+
+	return nil
+}
+
+func TestWatch_StreamsTransitions(t *testing.T) {
+	var healthy int32 = 1
+
+	s := &server{}
+	WithInterval(10 * time.Millisecond)(s)
+	WithHealthCheckFuncs(func() bool { return atomic.LoadInt32(&healthy) == 1 })(s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx, sent: make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 10)}
+
+	done := make(chan error)
+
+	go func() {
+		done <- s.Watch(&grpc_health_v1.HealthCheckRequest{}, stream)
+	}()
+
+	next := func() grpc_health_v1.HealthCheckResponse_ServingStatus {
+		select {
+		case st := <-stream.sent:
+			return st
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+
+		return 0
+	}
+
+	if st := next(); st != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %s", st)
+	}
+
+	atomic.StoreInt32(&healthy, 0)
+
+	if st := next(); st != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING, got %s", st)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+
+	if st := next(); st != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %s", st)
+	}
+
+	s.shutdown()
+
+	if st := next(); st != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING after shutdown, got %s", st)
+	}
+
+	cancel()
+
+	if err := <-done; status.Code(err) != codes.Canceled {
+		t.Fatalf("expected Canceled, got %v", err)
+	}
+}
+
+func TestWatch_Evaluator(t *testing.T) {
+	var healthy int32 = 1
+
+	e, err := healthcheck.NewEvaluator([]healthcheck.Check{
+		healthcheck.FromFunc("mysql", func() bool { return atomic.LoadInt32(&healthy) == 1 }),
+		healthcheck.FromFunc("redis", func() bool { return true }),
+	}, healthcheck.WithThresholds(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Evaluate()
+
+	s := &server{}
+	WithEvaluator(e)(s)
+	WithService("cache", e.Checks()[1])(s)
+
+	resp, err := s.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil || resp.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %v, %v", resp, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := &watchStream{ctx: ctx, sent: make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 10)}
+
+	go func() {
+		_ = s.Watch(&grpc_health_v1.HealthCheckRequest{}, stream)
+	}()
+
+	next := func() grpc_health_v1.HealthCheckResponse_ServingStatus {
+		select {
+		case st := <-stream.sent:
+			return st
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+
+		return 0
+	}
+
+	if st := next(); st != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %s", st)
+	}
+
+	atomic.StoreInt32(&healthy, 0)
+
+	for st := grpc_health_v1.HealthCheckResponse_SERVING; st != grpc_health_v1.HealthCheckResponse_NOT_SERVING; {
+		e.Evaluate()
+
+		select {
+		case st = <-stream.sent:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	resp, err = s.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "cache"})
+	if err != nil || resp.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING of cache, got %v, %v", resp, err)
+	}
+}
+
+func TestCheck_status(t *testing.T) {
+	tests := []struct {
+		name   string
+		server func() *server
+		want   grpc_health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name: "degraded service is serving",
+			server: func() *server {
+				s := &server{}
+				WithService("cache", healthcheck.Check{
+					Name:     "redis",
+					Optional: true,
+					Func:     func(context.Context) error { return errors.New("connection refused") },
+				})(s)
+
+				return s
+			},
+			want: grpc_health_v1.HealthCheckResponse_SERVING,
+		},
+		{
+			name: "stopped server is not serving",
+			server: func() *server {
+				s := &server{
+					fn: []daemon.HealthCheckFunc{
+						func() bool { return true },
+					},
+				}
+				s.shutdown()
+
+				return s
+			},
+			want: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.server().Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			if err != nil || resp.GetStatus() != tt.want {
+				t.Fatalf("expected %s, got %v, %v", tt.want, resp, err)
+			}
+		})
+	}
+}
+
+func TestWatch_SubscribeDuringUpdate(t *testing.T) {
+	s := &server{}
+	WithInterval(time.Hour)(s)
+	WithHealthCheckFuncs(func() bool { return true })(s)
+
+	ctx := context.Background()
+	first := s.subscribe(ctx, "")
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 1000; i++ {
+			ch := s.subscribe(ctx, "")
+			s.update("", grpc_health_v1.HealthCheckResponse_ServingStatus(i%2+1), false)
+			<-ch
+			s.unsubscribe("", ch)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscribe is blocked")
+	}
+
+	s.unsubscribe("", first)
+}
@@ -0,0 +1,299 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package health runs named health checks and reports their results.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/imega/daemon"
+)
+
+// ErrUnhealthy is returned by checks made of daemon.HealthCheckFunc.
+var ErrUnhealthy = errors.New("unhealthy")
+
+// Status of check.
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+	// StatusDegraded is the status of failed optional checks,
+	// the report is healthy.
+	StatusDegraded Status = "degraded"
+)
+
+// CheckFunc returns nil if the dependency is healthy.
+type CheckFunc func(ctx context.Context) error
+
+// Class of check selects the probes which run it.
+type Class string
+
+const (
+	// ClassLiveness checks fail if the process must be restarted.
+	ClassLiveness Class = "liveness"
+	// ClassReadiness checks fail if the process can't serve requests,
+	// e.g. a dependency is down.
+	ClassReadiness Class = "readiness"
+	// ClassStartup checks fail until the process has started.
+	ClassStartup Class = "startup"
+)
+
+// Check is a named health check.
+type Check struct {
+	Name string
+	Func CheckFunc
+
+	// Classes of check, a check without classes is a readiness
+	// and startup check.
+	Classes []Class
+
+	// Optional check is degraded instead of down if it fails,
+	// e.g. a cache.
+	Optional bool
+
+	// Timeout, FailureThreshold and SuccessThreshold override
+	// the options of Evaluator for the check if they are set.
+	Timeout          time.Duration
+	FailureThreshold int
+	SuccessThreshold int
+}
+
+// failed returns the status of check if it fails.
+func (c Check) failed() Status {
+	if c.Optional {
+		return StatusDegraded
+	}
+
+	return StatusDown
+}
+
+// Is reports whether the check belongs to class.
+func (c Check) Is(class Class) bool {
+	if len(c.Classes) == 0 {
+		return class == ClassReadiness || class == ClassStartup
+	}
+
+	for _, cl := range c.Classes {
+		if cl == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Select returns the checks of class.
+func Select(checks []Check, class Class) []Check {
+	res := make([]Check, 0, len(checks))
+
+	for _, c := range checks {
+		if c.Is(class) {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+// FromFunc returns a check of daemon.HealthCheckFunc,
+// it fails with ErrUnhealthy.
+func FromFunc(name string, f daemon.HealthCheckFunc) Check {
+	return Check{
+		Name: name,
+		Func: func(context.Context) error {
+			if !f() {
+				return ErrUnhealthy
+			}
+
+			return nil
+		},
+	}
+}
+
+// FromFuncs returns checks named check-1, check-2 and so on.
+func FromFuncs(f ...daemon.HealthCheckFunc) []Check {
+	checks := make([]Check, 0, len(f))
+	for i, fn := range f {
+		checks = append(checks, FromFunc(fmt.Sprintf("check-%d", i+1), fn))
+	}
+
+	return checks
+}
+
+// Result is the result of check.
+type Result struct {
+	Name        string        `json:"name"`
+	Status      Status        `json:"status"`
+	Duration    time.Duration `json:"-"`
+	Error       string        `json:"error,omitempty"`
+	LastSuccess *time.Time    `json:"last_success,omitempty"`
+}
+
+// MarshalJSON writes Duration as a string, e.g. 1.5ms.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+
+	b, err := json.Marshal(struct {
+		result
+		Duration string `json:"duration"`
+	}{result(r), r.Duration.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result, %w", err)
+	}
+
+	return b, nil
+}
+
+// Report is the results of all checks.
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Healthy reports whether all checks are up or degraded.
+func (r Report) Healthy() bool {
+	return r.Status != StatusDown
+}
+
+// Checker runs checks and keeps the time of their last success.
+type Checker struct {
+	checks []Check
+
+	mx          sync.Mutex
+	lastSuccess map[string]time.Time
+}
+
+// NewChecker get a instance of Checker.
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{
+		checks:      checks,
+		lastSuccess: make(map[string]time.Time),
+	}
+}
+
+// Checks returns the checks.
+func (c *Checker) Checks() []Check {
+	return c.checks
+}
+
+// Run runs the checks concurrently, the checks which have not finished
+// when ctx is done are down with the error of ctx.
+func (c *Checker) Run(ctx context.Context) Report {
+	return NewReport(c.runChecks(ctx, c.checks))
+}
+
+func (c *Checker) runChecks(ctx context.Context, checks []Check) []Result {
+	type done struct {
+		idx int
+		res Result
+	}
+
+	results := make([]Result, len(checks))
+	finished := make([]bool, len(checks))
+	doneCh := make(chan done, len(checks))
+	start := time.Now()
+
+	for i, check := range checks {
+		go func(i int, check Check) {
+			doneCh <- done{idx: i, res: c.run(ctx, check)}
+		}(i, check)
+	}
+
+wait:
+	for n := 0; n < len(checks); n++ {
+		select {
+		case d := <-doneCh:
+			results[d.idx] = d.res
+			finished[d.idx] = true
+		case <-ctx.Done():
+			break wait
+		}
+	}
+
+	for i, check := range checks {
+		if !finished[i] {
+			results[i] = Result{
+				Name:        check.Name,
+				Status:      check.failed(),
+				Duration:    time.Since(start),
+				Error:       ctx.Err().Error(),
+				LastSuccess: c.last(check.Name),
+			}
+		}
+	}
+
+	return results
+}
+
+// NewReport returns the report of results, it is down if any check
+// is down, degraded if any check is degraded and up otherwise.
+func NewReport(results []Result) Report {
+	rep := Report{Status: StatusUp, Checks: results}
+
+	for _, r := range results {
+		switch {
+		case r.Status == StatusDown:
+			rep.Status = StatusDown
+		case r.Status == StatusDegraded && rep.Status == StatusUp:
+			rep.Status = StatusDegraded
+		}
+	}
+
+	return rep
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	start := time.Now()
+	err := check.Func(ctx)
+
+	res := Result{
+		Name:     check.Name,
+		Status:   StatusUp,
+		Duration: time.Since(start),
+	}
+
+	c.mx.Lock()
+	if err == nil {
+		c.lastSuccess[check.Name] = start
+	}
+	c.mx.Unlock()
+
+	if err != nil {
+		res.Status = check.failed()
+		res.Error = err.Error()
+	}
+
+	res.LastSuccess = c.last(check.Name)
+
+	return res
+}
+
+func (c *Checker) last(name string) *time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	t, ok := c.lastSuccess[name]
+	if !ok {
+		return nil
+	}
+
+	return &t
+}
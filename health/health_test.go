@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecker_Run(t *testing.T) {
+	up := true
+	c := NewChecker(
+		FromFunc("mysql", func() bool { return up }),
+		Check{Name: "slow", Func: func(ctx context.Context) error {
+			time.Sleep(time.Second)
+
+			return nil
+		}},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	report := c.Run(ctx)
+
+	if report.Healthy() {
+		t.Fatalf("expected unhealthy report, %+v", report)
+	}
+
+	if r := report.Checks[0]; r.Status != StatusUp || r.LastSuccess == nil {
+		t.Errorf("unexpected result %+v", r)
+	}
+
+	if r := report.Checks[1]; r.Status != StatusDown || r.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("unexpected result %+v", r)
+	}
+
+	up = false
+	report = NewChecker(c.Checks()[0]).Run(context.Background())
+
+	if r := report.Checks[0]; r.Status != StatusDown || r.Error != ErrUnhealthy.Error() {
+		t.Errorf("unexpected result %+v", r)
+	}
+
+	b, err := json.Marshal(report.Checks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), `"duration":"`) {
+		t.Errorf("expected duration in %s", b)
+	}
+}
+
+func TestReport_Degraded(t *testing.T) {
+	c := NewChecker(
+		FromFunc("mysql", func() bool { return true }),
+		Check{Name: "redis", Optional: true, Func: func(context.Context) error { return ErrUnhealthy }},
+	)
+
+	report := c.Run(context.Background())
+	if report.Status != StatusDegraded || !report.Healthy() {
+		t.Fatalf("expected degraded healthy report, %+v", report)
+	}
+
+	if r := report.Checks[1]; r.Status != StatusDegraded || r.Error != ErrUnhealthy.Error() {
+		t.Errorf("unexpected result %+v", r)
+	}
+
+	report = NewReport(append(report.Checks, Result{Name: "amqp", Status: StatusDown}))
+	if report.Status != StatusDown || report.Healthy() {
+		t.Fatalf("expected down report, %+v", report)
+	}
+}
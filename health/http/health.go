@@ -16,36 +16,85 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/imega/daemon"
+	healthcheck "github.com/imega/daemon/health"
 )
 
 const defaultTimeout = 60
 
 type health struct {
-	hcf     []daemon.HealthCheckFunc
-	timeout time.Duration
+	hcf       []daemon.HealthCheckFunc
+	checks    []healthcheck.Check
+	timeout   time.Duration
+	checker   *healthcheck.Checker
+	evaluator *healthcheck.Evaluator
+	daemon    daemon.Daemon
+
+	class    healthcheck.Class
+	started  int32
+	draining int32
 }
 
 // Handler returns an http.Handler
 //
 // It returns status 204 if all healthcheckers returns true.
 // It returns status 503 (unhealthy) if anyone healthcheckers returns false.
+// The failed optional checks are degraded, they don't fail the handler.
+//
+// The detailed report in JSON is returned if the request has the verbose
+// query parameter or accepts application/json, the status is 200 or 503.
+//
+//	{
+//	  "status": "down",
+//	  "checks": [
+//	    {
+//	      "name": "mysql",
+//	      "status": "down",
+//	      "error": "unhealthy",
+//	      "last_success": "2022-10-19T10:00:00Z",
+//	      "duration": "1.5ms"
+//	    }
+//	  ]
+//	}
 func Handler(opts ...Option) http.Handler {
+	return newHandler("", opts...)
+}
+
+// newHandler returns the handler of checks of class, all checks
+// if class is empty.
+func newHandler(class healthcheck.Class, opts ...Option) *health {
 	handler := &health{
 		timeout: defaultTimeout * time.Second,
+		class:   class,
 	}
 
 	for _, opt := range opts {
 		opt(handler)
 	}
 
+	checks := append(healthcheck.FromFuncs(handler.hcf...), handler.checks...)
+	handler.checker = healthcheck.NewChecker(handler.selectChecks(checks)...)
+
+	if class == healthcheck.ClassReadiness && handler.daemon != nil {
+		handler.daemon.RegisterDrainFunc(handler.drain)
+	}
+
 	return handler
 }
 
+func (h *health) selectChecks(checks []healthcheck.Check) []healthcheck.Check {
+	if h.class == "" {
+		return checks
+	}
+
+	return healthcheck.Select(checks, h.class)
+}
+
 // HandlerFunc returns an http.HandlerFunc.
 func HandlerFunc(opts ...Option) http.HandlerFunc {
 	return Handler(opts...).ServeHTTP
@@ -62,6 +111,32 @@ func WithHealthCheckFuncs(f ...daemon.HealthCheckFunc) Option {
 	}
 }
 
+// WithChecks adds the named checks, their names are shown
+// in the detailed report. The HealthCheckFuncs are named check-N.
+func WithChecks(checks ...healthcheck.Check) Option {
+	return func(h *health) {
+		h.checks = append(h.checks, checks...)
+	}
+}
+
+// WithEvaluator serves the cached results of evaluator instead of
+// running the checks on every request, the other checks are ignored.
+// The evaluator must be started.
+func WithEvaluator(e *healthcheck.Evaluator) Option {
+	return func(h *health) {
+		h.evaluator = e
+	}
+}
+
+// WithDaemon fails readiness as soon as the daemon shutdown begins,
+// before the servers stop, see daemon.WithDrainDelay. It affects
+// Readyz only.
+func WithDaemon(d daemon.Daemon) Option {
+	return func(h *health) {
+		h.daemon = d
+	}
+}
+
 // WithTimeout sets the global timeout for all healthcheckers.
 func WithTimeout(timeout time.Duration) Option {
 	return func(h *health) {
@@ -70,6 +145,25 @@ func WithTimeout(timeout time.Duration) Option {
 }
 
 func (h *health) ServeHTTP(resp http.ResponseWriter, r *http.Request) {
+	respond(resp, r, h.probe(r))
+}
+
+func (h *health) report(r *http.Request) healthcheck.Report {
+	if h.evaluator != nil {
+		if h.class == "" {
+			return h.evaluator.Report()
+		}
+
+		checks := h.selectChecks(h.evaluator.Checks())
+		names := make([]string, 0, len(checks))
+
+		for _, c := range checks {
+			names = append(names, c.Name)
+		}
+
+		return h.evaluator.ReportOf(names...)
+	}
+
 	ctx, cancel := r.Context(), func() {}
 	if h.timeout > 0 {
 		ctx, cancel = context.WithTimeout(ctx, h.timeout)
@@ -77,43 +171,42 @@ func (h *health) ServeHTTP(resp http.ResponseWriter, r *http.Request) {
 
 	defer cancel()
 
-	statusCh := make(chan bool, len(h.hcf))
-	wGroup := sync.WaitGroup{}
-
-	wGroup.Add(len(h.hcf))
+	return h.checker.Run(ctx)
+}
 
-	for _, f := range h.hcf {
-		go func(f daemon.HealthCheckFunc) {
-			statusCh <- f()
+func respond(resp http.ResponseWriter, r *http.Request, report healthcheck.Report) {
+	if detailed(r) {
+		writeReport(resp, report)
 
-			wGroup.Done()
-		}(f)
+		return
 	}
 
-	go func() {
-		wGroup.Wait()
-		close(statusCh)
-	}()
-
-	for {
-		select {
-		case <-ctx.Done():
-			http.Error(resp, "unhealthy", http.StatusServiceUnavailable)
+	if !report.Healthy() {
+		http.Error(resp, "unhealthy", http.StatusServiceUnavailable)
 
-			return
+		return
+	}
 
-		case status, ok := <-statusCh:
-			if !ok {
-				resp.WriteHeader(http.StatusNoContent)
+	resp.WriteHeader(http.StatusNoContent)
+}
 
-				return
-			}
+// detailed reports whether the request asks for the JSON report.
+func detailed(r *http.Request) bool {
+	if v, ok := r.URL.Query()["verbose"]; ok && (len(v) == 0 || (v[0] != "0" && v[0] != "false")) {
+		return true
+	}
 
-			if !status {
-				http.Error(resp, "unhealthy", http.StatusServiceUnavailable)
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
 
-				return
-			}
-		}
+func writeReport(resp http.ResponseWriter, report healthcheck.Report) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
 	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+
+	_ = json.NewEncoder(resp).Encode(report)
 }
@@ -1,10 +1,16 @@
 package http
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/imega/daemon"
+	healthcheck "github.com/imega/daemon/health"
 )
 
 func TestHandler(t *testing.T) {
@@ -109,3 +115,204 @@ func TestHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_detailed(t *testing.T) {
+	h := Handler(
+		WithHealthCheckFuncs(func() bool { return true }),
+		WithChecks(healthcheck.Check{
+			Name: "mysql",
+			Func: func(context.Context) error { return errors.New("connection refused") },
+		}),
+	)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/healthcheck?verbose", nil),
+		func() *http.Request {
+			req := httptest.NewRequest(http.MethodGet, "/healthcheck", nil)
+			req.Header.Set("Accept", "application/json")
+
+			return req
+		}(),
+	} {
+		ht := httptest.NewRecorder()
+		h.ServeHTTP(ht, req)
+
+		if ht.Code != http.StatusServiceUnavailable {
+			t.Errorf("Handler() = %d, want %d", ht.Code, http.StatusServiceUnavailable)
+		}
+
+		report := healthcheck.Report{}
+		if err := json.NewDecoder(ht.Body).Decode(&report); err != nil {
+			t.Fatalf("failed to decode report, %s", err)
+		}
+
+		if report.Status != healthcheck.StatusDown || len(report.Checks) != 2 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+
+		if c := report.Checks[0]; c.Name != "check-1" || c.Status != healthcheck.StatusUp || c.LastSuccess == nil {
+			t.Errorf("unexpected check %+v", c)
+		}
+
+		if c := report.Checks[1]; c.Name != "mysql" || c.Error != "connection refused" || c.LastSuccess != nil {
+			t.Errorf("unexpected check %+v", c)
+		}
+	}
+}
+
+func TestHandler_evaluator(t *testing.T) {
+	calls := 0
+	e, err := healthcheck.NewEvaluator([]healthcheck.Check{
+		healthcheck.FromFunc("mysql", func() bool {
+			calls++
+
+			return true
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Evaluate()
+
+	h := Handler(WithEvaluator(e))
+
+	for i := 0; i < 3; i++ {
+		ht := httptest.NewRecorder()
+		h.ServeHTTP(ht, httptest.NewRequest(http.MethodGet, "/healthcheck", nil))
+
+		if ht.Code != http.StatusNoContent {
+			t.Errorf("Handler() = %d, want %d", ht.Code, http.StatusNoContent)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the cached result, the check is called %d times", calls)
+	}
+}
+
+type fakeDaemon struct {
+	daemon.Daemon
+	sf []daemon.ShutdownFunc
+}
+
+func (d *fakeDaemon) RegisterDrainFunc(f ...daemon.ShutdownFunc) {
+	d.sf = append(d.sf, f...)
+}
+
+func TestProbes(t *testing.T) {
+	var dbUp, started bool
+
+	d := &fakeDaemon{}
+	opts := []Option{
+		WithHealthCheckFuncs(func() bool { return dbUp }),
+		WithChecks(
+			healthcheck.Check{
+				Name:    "deadlock",
+				Classes: []healthcheck.Class{healthcheck.ClassLiveness},
+				Func:    func(context.Context) error { return nil },
+			},
+			healthcheck.Check{
+				Name:    "migrations",
+				Classes: []healthcheck.Class{healthcheck.ClassStartup, healthcheck.ClassReadiness},
+				Func: func(context.Context) error {
+					if !started {
+						return errors.New("in progress")
+					}
+
+					return nil
+				},
+			},
+		),
+		WithDaemon(d),
+	}
+
+	livez, readyz, startupz := Livez(opts...), Readyz(opts...), Startupz(opts...)
+
+	probe := func(h http.Handler) int {
+		ht := httptest.NewRecorder()
+		h.ServeHTTP(ht, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		return ht.Code
+	}
+
+	if len(d.sf) != 1 {
+		t.Fatalf("expected a drain func of readiness, got %d", len(d.sf))
+	}
+
+	steps := []struct {
+		dbUp, started           bool
+		livez, readyz, startupz int
+	}{
+		{false, false, http.StatusNoContent, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		{true, true, http.StatusNoContent, http.StatusNoContent, http.StatusNoContent},
+		{false, false, http.StatusNoContent, http.StatusServiceUnavailable, http.StatusNoContent},
+		{true, true, http.StatusNoContent, http.StatusNoContent, http.StatusNoContent},
+	}
+
+	for i, step := range steps {
+		dbUp, started = step.dbUp, step.started
+
+		if code := probe(livez); code != step.livez {
+			t.Errorf("step %d: livez = %d, want %d", i, code, step.livez)
+		}
+
+		if code := probe(readyz); code != step.readyz {
+			t.Errorf("step %d: readyz = %d, want %d", i, code, step.readyz)
+		}
+
+		if code := probe(startupz); code != step.startupz {
+			t.Errorf("step %d: startupz = %d, want %d", i, code, step.startupz)
+		}
+	}
+
+	d.sf[0]()
+
+	if code := probe(readyz); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz = %d after shutdown, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	if code := probe(livez); code != http.StatusNoContent {
+		t.Errorf("livez = %d after shutdown, want %d", code, http.StatusNoContent)
+	}
+}
+
+func TestHandler_degraded(t *testing.T) {
+	h := Readyz(WithChecks(healthcheck.Check{
+		Name:     "redis",
+		Optional: true,
+		Func:     func(context.Context) error { return errors.New("connection refused") },
+	}))
+
+	ht := httptest.NewRecorder()
+	h.ServeHTTP(ht, httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil))
+
+	if ht.Code != http.StatusOK {
+		t.Errorf("Handler() = %d, want %d", ht.Code, http.StatusOK)
+	}
+
+	report := healthcheck.Report{}
+	if err := json.NewDecoder(ht.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report, %s", err)
+	}
+
+	if report.Status != healthcheck.StatusDegraded || report.Checks[0].Status != healthcheck.StatusDegraded {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestStartupz_healthCheckFuncs(t *testing.T) {
+	up := false
+	startupz := Startupz(WithHealthCheckFuncs(func() bool { return up }))
+
+	for i, want := range []int{http.StatusServiceUnavailable, http.StatusNoContent} {
+		ht := httptest.NewRecorder()
+		startupz.ServeHTTP(ht, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if ht.Code != want {
+			t.Errorf("step %d: startupz = %d, want %d", i, ht.Code, want)
+		}
+
+		up = true
+	}
+}
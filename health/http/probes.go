@@ -0,0 +1,78 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	healthcheck "github.com/imega/daemon/health"
+)
+
+// ErrShuttingDown is the error of readiness since the daemon
+// shutdown begins.
+var ErrShuttingDown = errors.New("shutting down")
+
+// Livez returns the handler of liveness probe, it runs the checks
+// of class liveness only, so an outage of dependency doesn't restart
+// the process.
+//
+//	mux.Handle("/livez", health.Livez(opts...))
+//	mux.Handle("/readyz", health.Readyz(health.WithDaemon(d), opts...))
+//	mux.Handle("/startupz", health.Startupz(opts...))
+func Livez(opts ...Option) http.Handler {
+	return newHandler(healthcheck.ClassLiveness, opts...)
+}
+
+// Readyz returns the handler of readiness probe, it runs the checks
+// of class readiness, the checks without class and the HealthCheckFuncs.
+// See WithDaemon.
+func Readyz(opts ...Option) http.Handler {
+	return newHandler(healthcheck.ClassReadiness, opts...)
+}
+
+// Startupz returns the handler of startup probe, it runs the checks
+// of class startup, the checks without class and the HealthCheckFuncs
+// until they pass once.
+func Startupz(opts ...Option) http.Handler {
+	return newHandler(healthcheck.ClassStartup, opts...)
+}
+
+func (h *health) probe(r *http.Request) healthcheck.Report {
+	if h.class == healthcheck.ClassStartup && atomic.LoadInt32(&h.started) == 1 {
+		return healthcheck.NewReport(nil)
+	}
+
+	report := h.report(r)
+
+	if h.class == healthcheck.ClassStartup && report.Healthy() {
+		atomic.StoreInt32(&h.started, 1)
+	}
+
+	if atomic.LoadInt32(&h.draining) == 1 {
+		report = healthcheck.NewReport(append(report.Checks, healthcheck.Result{
+			Name:   "shutdown",
+			Status: healthcheck.StatusDown,
+			Error:  ErrShuttingDown.Error(),
+		}))
+	}
+
+	return report
+}
+
+func (h *health) drain() {
+	atomic.StoreInt32(&h.draining, 1)
+}
@@ -47,7 +47,10 @@ type Config struct {
 	MaxHeaderBytes    int
 }
 
-const defaultTimeout = 2 * time.Second
+const (
+	defaultAddr    = "0.0.0.0:65534"
+	defaultTimeout = 2 * time.Second
+)
 
 type Option func(*Connector)
 
@@ -67,7 +70,7 @@ func WithHandler(o http.Handler) Option {
 func New(prefix string, opts ...Option) *Connector {
 	conn := &Connector{
 		conf: &Config{
-			Addr:         "0.0.0.0:65534",
+			Addr:         defaultAddr,
 			ReadTimeout:  defaultTimeout,
 			WriteTimeout: defaultTimeout,
 		},
@@ -83,7 +86,8 @@ func New(prefix string, opts ...Option) *Connector {
 		return daemon.WatcherConfig{
 			Prefix:    prefix,
 			MainKey:   "http-server",
-			Keys:      keys(),
+			Keys:      schema().Names(),
+			Schema:    schema(),
 			ApplyFunc: conn.connect,
 		}
 	}
@@ -94,7 +98,7 @@ func New(prefix string, opts ...Option) *Connector {
 		}
 
 		if err := conn.srv.Shutdown(context.Background()); err != nil {
-			conn.log.Errorf("%s", err)
+			conn.log.WithError(err).Errorf("failed to shutdown http server")
 		}
 	}
 
@@ -127,7 +131,7 @@ func (c *Connector) connect(conf, last map[string]string) {
 		c.log.Debugf("http connector start shutdown, %s", c.conf.Addr)
 
 		if err := c.srv.Shutdown(context.Background()); err != nil {
-			c.log.Errorf("%s", err)
+			c.log.WithError(err).Errorf("failed to shutdown http server")
 		}
 
 		c.log.Debugf("http connector end shutdown")
@@ -141,20 +145,47 @@ func (c *Connector) connect(conf, last map[string]string) {
 
 		if err := c.srv.ListenAndServe(); err != nil {
 			if !errors.Is(err, http.ErrServerClosed) {
-				c.log.Errorf("%s", err)
+				c.log.WithError(err).Errorf("failed to serve http")
 			}
 		}
 	}()
 }
 
-func keys() []string {
-	return []string{
-		"host",
-		"read-timeout",
-		"read-header-timeout",
-		"write-timeout",
-		"idle-timeout",
-		"max-header-bytes",
+func schema() daemon.Schema {
+	return daemon.Schema{
+		{
+			Name:        "host",
+			Type:        daemon.TypeString,
+			Default:     defaultAddr,
+			Description: "Address to listen on, host:port.",
+		},
+		{
+			Name:        "read-timeout",
+			Type:        daemon.TypeDuration,
+			Default:     defaultTimeout.String(),
+			Description: "Maximum duration for reading the entire request.",
+		},
+		{
+			Name:        "read-header-timeout",
+			Type:        daemon.TypeDuration,
+			Description: "Amount of time allowed to read request headers.",
+		},
+		{
+			Name:        "write-timeout",
+			Type:        daemon.TypeDuration,
+			Default:     defaultTimeout.String(),
+			Description: "Maximum duration before timing out writes of the response.",
+		},
+		{
+			Name:        "idle-timeout",
+			Type:        daemon.TypeDuration,
+			Description: "Maximum amount of time to wait for the next request.",
+		},
+		{
+			Name:        "max-header-bytes",
+			Type:        daemon.TypeInt,
+			Description: "Maximum number of bytes of the request header.",
+		},
 	}
 }
 
@@ -209,7 +240,7 @@ func (c *Connector) reset(last map[string]string) bool {
 		switch k {
 		case c.prefix + "/http-server/host":
 			needUpdate = true
-			c.conf.Addr = "0.0.0.0:65534"
+			c.conf.Addr = defaultAddr
 
 		case c.prefix + "/http-server/read-timeout":
 			needUpdate = true
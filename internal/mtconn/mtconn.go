@@ -0,0 +1,41 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mtconn creates MassTransport of the connectors
+// of packages amqp and masstransport.
+package mtconn
+
+import (
+	"github.com/imega/daemon/logging"
+	"github.com/imega/mt"
+)
+
+// NewFunc creates MassTransport.
+type NewFunc func(dsn string, log logging.Logger, conf mt.Config) mt.MassTransport
+
+// New creates MassTransport of package mt.
+func New(dsn string, log logging.Logger, conf mt.Config) mt.MassTransport {
+	return mt.NewMT(mt.WithAMQP(dsn), mt.WithLogger(log), mt.WithConfig(conf))
+}
+
+// Connect creates MassTransport by fn. The DSN is passed as is,
+// the logger is redacted, so the logs of MassTransport don't
+// leak the password of DSN.
+func Connect(fn NewFunc, dsn string, log logging.Logger, conf mt.Config) mt.MassTransport {
+	if log == nil {
+		log = logging.GetNoopLog()
+	}
+
+	return fn(dsn, logging.Redact(log), conf)
+}
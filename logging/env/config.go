@@ -23,10 +23,12 @@ func ReadConfig() logging.Config {
 	ch, _ := env.Read("LOG_CHANNEL")
 	buildID, _ := env.Read("LOG_BUILD_ID")
 	level, _ := env.Read("LOG_LEVEL")
+	file, _ := env.Read("LOG_FILE")
 
 	return logging.Config{
 		Channel: ch,
 		Level:   level,
 		BuildID: buildID,
+		File:    file,
 	}
 }
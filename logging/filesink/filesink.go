@@ -0,0 +1,480 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filesink writes logs to a file with size- and time-based
+// rotation, compression of rotated files and reopen on SIGHUP.
+package filesink
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/logging"
+)
+
+const (
+	mainKey    = "log-file"
+	timeFormat = "2006-01-02T15-04-05.000"
+	fileMode   = 0o644
+)
+
+// Config is the configuration of File.
+type Config struct {
+	// Path of file, the logs are written to os.Stderr if it is empty.
+	Path string
+
+	// MaxSize in bytes, the file is rotated before it exceeds MaxSize.
+	// Zero disables size-based rotation.
+	MaxSize int64
+
+	// Interval of time-based rotation, e.g. 24h.
+	// Zero disables time-based rotation.
+	Interval time.Duration
+
+	// MaxBackups is the number of rotated files kept,
+	// zero keeps all files.
+	MaxBackups int
+
+	// Compress rotated files by gzip.
+	Compress bool
+}
+
+// File is an io.Writer of logs. Rotated files are named
+// <name>-<time><ext>, e.g. app-2022-10-19T10-00-00.000.log.
+//
+// Its ReloadFunc reopens the file, it is used with logrotate.
+// Its WatcherConfigFunc watches prefix/log-file/path, max-size,
+// interval, max-backups and compress.
+type File struct {
+	prefix string
+	def    Config
+	now    func() time.Time
+
+	mx       sync.Mutex
+	conf     Config
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+	compress sync.WaitGroup
+	cleanMx  sync.Mutex
+	errOut   io.Writer
+
+	daemon.WatcherConfigFunc
+	daemon.ReloadFunc
+	daemon.ShutdownFunc
+}
+
+// New get a instance of File. The configuration in watcher
+// keys overrides conf.
+func New(prefix string, conf Config) (*File, error) {
+	f := &File{
+		prefix: prefix,
+		def:    conf,
+		now:    time.Now,
+		conf:   conf,
+		errOut: os.Stderr,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	f.WatcherConfigFunc = func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:       prefix,
+			MainKey:      mainKey,
+			Keys:         schema(conf).Names(),
+			Schema:       schema(conf),
+			ApplyChanges: f.apply,
+		}
+	}
+
+	f.ReloadFunc = func() {
+		if err := f.Reopen(); err != nil {
+			fmt.Fprintf(f.errOut, "%s\n", err)
+		}
+	}
+
+	f.ShutdownFunc = func() {
+		if err := f.Close(); err != nil {
+			fmt.Fprintf(f.errOut, "%s\n", err)
+		}
+	}
+
+	return f, nil
+}
+
+// FromConfig get a instance of File with the path in Config.File.
+func FromConfig(prefix string, conf logging.Config) (*File, error) {
+	return New(prefix, Config{Path: conf.File})
+}
+
+func schema(def Config) daemon.Schema {
+	return daemon.Schema{
+		{
+			Name:        "path",
+			Type:        daemon.TypeString,
+			Default:     def.Path,
+			Description: "Path of log file, stderr if it is empty.",
+		},
+		{
+			Name:        "max-size",
+			Type:        daemon.TypeInt,
+			Default:     strconv.FormatInt(def.MaxSize, 10),
+			Description: "Size of log file in bytes before rotation, 0 disables it.",
+		},
+		{
+			Name:        "interval",
+			Type:        daemon.TypeDuration,
+			Default:     def.Interval.String(),
+			Description: "Interval of rotation, 0 disables it.",
+		},
+		{
+			Name:        "max-backups",
+			Type:        daemon.TypeInt,
+			Default:     strconv.Itoa(def.MaxBackups),
+			Description: "Number of rotated files kept, 0 keeps all.",
+		},
+		{
+			Name:        "compress",
+			Type:        daemon.TypeBool,
+			Default:     strconv.FormatBool(def.Compress),
+			Description: "Compress rotated files by gzip.",
+		},
+	}
+}
+
+func (f *File) apply(cs daemon.ChangeSet) {
+	base := f.prefix + "/" + mainKey + "/"
+	conf := f.def
+
+	for k, v := range cs.Conf {
+		v = strings.TrimSpace(v)
+
+		switch k {
+		case base + "path":
+			conf.Path = v
+
+		case base + "max-size":
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				conf.MaxSize = n
+			}
+
+		case base + "interval":
+			if d, err := time.ParseDuration(v); err == nil {
+				conf.Interval = d
+			}
+
+		case base + "max-backups":
+			if n, err := strconv.Atoi(v); err == nil {
+				conf.MaxBackups = n
+			}
+
+		case base + "compress":
+			if b, err := strconv.ParseBool(v); err == nil {
+				conf.Compress = b
+			}
+		}
+	}
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	prev := f.conf.Path
+	f.conf = conf
+
+	if conf.Path == prev || f.closed {
+		return
+	}
+
+	f.closeFile()
+
+	if err := f.open(); err != nil {
+		fmt.Fprintf(f.errOut, "%s, keep writing to %q\n", err, prev)
+
+		f.conf.Path = prev
+		if err := f.open(); err != nil {
+			fmt.Fprintf(f.errOut, "%s\n", err)
+		}
+	}
+}
+
+// Write writes p to the file, the file is rotated before
+// if it exceeds MaxSize or Interval elapsed.
+func (f *File) Write(p []byte) (int, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if f.conf.Path == "" || f.closed {
+		return os.Stderr.Write(p)
+	}
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.needRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	if err != nil {
+		return n, fmt.Errorf("failed to write log, %w", err)
+	}
+
+	return n, nil
+}
+
+// Rotate renames the file and opens a new one.
+func (f *File) Rotate() error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if f.conf.Path == "" || f.closed {
+		return nil
+	}
+
+	return f.rotate()
+}
+
+// Reopen closes and opens the file by path, it is used when
+// the file has been moved by logrotate.
+func (f *File) Reopen() error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if f.closed {
+		return nil
+	}
+
+	f.closeFile()
+
+	return f.open()
+}
+
+// Sync commits the file to disk and waits for compression
+// of rotated files.
+func (f *File) Sync() error {
+	f.mx.Lock()
+	file := f.file
+	f.mx.Unlock()
+
+	f.compress.Wait()
+
+	if file == nil {
+		return nil
+	}
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log file, %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file and waits for compression of rotated files,
+// the logs are written to os.Stderr after it.
+func (f *File) Close() error {
+	f.mx.Lock()
+	file := f.file
+	f.file = nil
+	f.closed = true
+	f.mx.Unlock()
+
+	f.compress.Wait()
+
+	if file == nil {
+		return nil
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file, %w", err)
+	}
+
+	return nil
+}
+
+func (f *File) needRotate(n int64) bool {
+	if f.conf.MaxSize > 0 && f.size > 0 && f.size+n > f.conf.MaxSize {
+		return true
+	}
+
+	if f.conf.Interval > 0 {
+		next := f.openedAt.Truncate(f.conf.Interval).Add(f.conf.Interval)
+
+		return !f.now().Before(next)
+	}
+
+	return false
+}
+
+func (f *File) open() error {
+	if f.conf.Path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.conf.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log dir, %w", err)
+	}
+
+	file, err := os.OpenFile(f.conf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, fileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open log file, %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return fmt.Errorf("failed to stat log file, %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+
+	return nil
+}
+
+func (f *File) closeFile() {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+func (f *File) rotate() error {
+	f.closeFile()
+
+	name := f.backupName(f.now())
+	if err := os.Rename(f.conf.Path, name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to rename log file, %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	conf := f.conf
+
+	f.compress.Add(1)
+
+	go func() {
+		defer f.compress.Done()
+
+		f.cleanMx.Lock()
+		defer f.cleanMx.Unlock()
+
+		if conf.Compress {
+			if err := compress(name); err != nil {
+				fmt.Fprintf(f.errOut, "%s\n", err)
+			}
+		}
+
+		removeBackups(conf)
+	}()
+
+	return nil
+}
+
+func (f *File) backupName(t time.Time) string {
+	dir, base := filepath.Split(f.conf.Path)
+	ext := filepath.Ext(base)
+
+	return filepath.Join(dir, strings.TrimSuffix(base, ext)+"-"+t.Format(timeFormat)+ext)
+}
+
+func compress(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open rotated log, %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fileMode)
+	if err != nil {
+		return fmt.Errorf("failed to create compressed log, %w", err)
+	}
+	defer dst.Close()
+
+	zw := gzip.NewWriter(dst)
+
+	if _, err := io.Copy(zw, src); err != nil {
+		return fmt.Errorf("failed to compress log, %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress log, %w", err)
+	}
+
+	return os.Remove(name)
+}
+
+// removeBackups removes the oldest rotated files over MaxBackups.
+func removeBackups(conf Config) {
+	if conf.MaxBackups <= 0 {
+		return
+	}
+
+	dir, base := filepath.Split(conf.Path)
+	ext := filepath.Ext(base)
+
+	name := strings.TrimSuffix(base, ext)
+
+	matches, err := filepath.Glob(filepath.Join(dir, name+"-*"+ext+"*"))
+	if err != nil {
+		return
+	}
+
+	backups := make(map[string][]string)
+
+	for _, m := range matches {
+		t := strings.TrimPrefix(filepath.Base(m), name+"-")
+		t = strings.TrimSuffix(strings.TrimSuffix(t, ".gz"), ext)
+
+		if _, err := time.Parse(timeFormat, t); err == nil {
+			backups[t] = append(backups[t], m)
+		}
+	}
+
+	times := make([]string, 0, len(backups))
+	for t := range backups {
+		times = append(times, t)
+	}
+
+	sort.Strings(times)
+
+	for ; len(times) > conf.MaxBackups; times = times[1:] {
+		for _, m := range backups[times[0]] {
+			_ = os.Remove(m)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package filesink
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imega/daemon"
+)
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func backups(t *testing.T, dir string) []string {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "app-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(matches)
+
+	return matches
+}
+
+func TestFile_rotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := New("my-daemon", Config{Path: path, MaxSize: 10, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	now := time.Date(2022, 10, 19, 10, 0, 0, 0, time.UTC)
+	f.now = func() time.Time {
+		now = now.Add(time.Second)
+
+		return now
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, path); got != "fourth\n" {
+		t.Errorf("unexpected log %q", got)
+	}
+
+	files := backups(t, dir)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 backups, got %v", files)
+	}
+
+	zf, err := os.Open(files[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zf.Close()
+
+	zr, err := gzip.NewReader(zf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "third\n" {
+		t.Errorf("unexpected backup %q", b)
+	}
+}
+
+func TestFile_rotateByInterval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	now := time.Date(2022, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	f, err := New("my-daemon", Config{Path: path, Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.now = func() time.Time { return now }
+	f.openedAt = now
+
+	f.Write([]byte("first\n"))
+
+	now = now.Add(time.Hour)
+
+	f.Write([]byte("second\n"))
+	f.Sync()
+
+	if got := readFile(t, path); got != "second\n" {
+		t.Errorf("unexpected log %q", got)
+	}
+
+	if got := readFile(t, filepath.Join(dir, "app-2022-10-19T11-00-00.000.log")); got != "first\n" {
+		t.Errorf("unexpected backup %q", got)
+	}
+}
+
+func TestFile_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := New("my-daemon", Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("first\n"))
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+
+	f.ReloadFunc()
+	f.Write([]byte("second\n"))
+
+	if got := readFile(t, path); got != "second\n" {
+		t.Errorf("unexpected log %q", got)
+	}
+
+	if got := readFile(t, path+".1"); got != "first\n" {
+		t.Errorf("unexpected moved log %q", got)
+	}
+}
+
+func TestFile_apply(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := New("my-daemon", Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	path := filepath.Join(dir, "app.log")
+	f.WatcherConfigFunc().ApplyChanges(daemon.NewChangeSet(map[string]string{
+		"my-daemon/log-file/path": path,
+	}, nil))
+
+	f.Write([]byte("first\n"))
+
+	if got := readFile(t, path); got != "first\n" {
+		t.Errorf("unexpected log %q", got)
+	}
+}
+
+func TestFile_apply_keepsFileOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := New("my-daemon", Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	errOut := &strings.Builder{}
+	f.errOut = errOut
+
+	notDir := filepath.Join(dir, "not-dir")
+	if err := os.WriteFile(notDir, nil, fileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	f.WatcherConfigFunc().ApplyChanges(daemon.NewChangeSet(map[string]string{
+		"my-daemon/log-file/path": filepath.Join(notDir, "app.log"),
+	}, nil))
+
+	f.Write([]byte("first\n"))
+
+	if got := readFile(t, path); got != "first\n" {
+		t.Errorf("unexpected log %q", got)
+	}
+
+	if !strings.Contains(errOut.String(), "failed to create log dir") {
+		t.Errorf("unexpected error output %q", errOut.String())
+	}
+}
+
+func TestFile_ShutdownFunc(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := New("my-daemon", Config{Path: path, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Write([]byte("first\n"))
+
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	file := f.file
+
+	f.ShutdownFunc()
+
+	if err := file.Close(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected closed file, got %v", err)
+	}
+
+	files := backups(t, dir)
+	if len(files) != 1 || filepath.Ext(files[0]) != ".gz" {
+		t.Errorf("expected compressed backup, got %v", files)
+	}
+
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.file != nil {
+		t.Error("file is reopened after shutdown")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gelf sends logs to Graylog by GELF over UDP or TCP.
+package gelf
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/imega/daemon/logging"
+	"github.com/imega/daemon/logging/internal/netconn"
+)
+
+const (
+	version = "1.1"
+
+	// DefaultChunkSize is the size of UDP chunks suitable for WAN.
+	DefaultChunkSize = 1420
+
+	chunkHeaderSize = 12
+	maxChunks       = 128
+)
+
+// ErrTooManyChunks is returned if a message exceeds 128 chunks.
+var ErrTooManyChunks = errors.New("gelf message exceeds 128 chunks")
+
+var invalidFieldChars = regexp.MustCompile(`[^\w.\-]`)
+
+// Sink is a logging.Sink which sends GELF messages, the fields
+// are additional fields of message, e.g. _channel and _build_id.
+type Sink struct {
+	conn      *netconn.Conn
+	host      string
+	chunkSize int
+}
+
+// Option .
+type Option func(*Sink)
+
+// WithHost sets the host of messages, the hostname by default.
+func WithHost(host string) Option {
+	return func(s *Sink) {
+		s.host = host
+	}
+}
+
+// WithChunkSize sets the size of UDP chunks including the header.
+func WithChunkSize(n int) Option {
+	return func(s *Sink) {
+		s.chunkSize = n
+	}
+}
+
+// New get a instance of Sink, network is udp or tcp.
+// UDP messages are chunked, TCP messages are null-byte delimited.
+func New(network, addr string, opts ...Option) (*Sink, error) {
+	host, _ := os.Hostname()
+
+	s := &Sink{host: host, chunkSize: DefaultChunkSize}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.chunkSize <= chunkHeaderSize {
+		s.chunkSize = DefaultChunkSize
+	}
+
+	conn, err := netconn.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s.conn = conn
+
+	return s, nil
+}
+
+// NewLogger returns logging.Logger which sends to Graylog.
+func NewLogger(conf logging.Config, network, addr string, opts ...Option) (logging.Logger, error) {
+	s, err := New(network, addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return logging.NewSinkLogger(s, conf), nil
+}
+
+// Send implements logging.Sink.
+func (s *Sink) Send(r logging.Record) error {
+	msg, err := s.message(r)
+	if err != nil {
+		return err
+	}
+
+	if s.conn.Stream() {
+		return s.conn.Write(append(msg, 0))
+	}
+
+	chunks, err := s.chunks(msg)
+	if err != nil {
+		return err
+	}
+
+	return s.conn.Write(chunks...)
+}
+
+// Close closes the connection.
+func (s *Sink) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Sink) message(r logging.Record) ([]byte, error) {
+	m := map[string]interface{}{
+		"version":   version,
+		"host":      s.host,
+		"timestamp": float64(r.Time.UnixNano()/int64(1e6)) / 1e3,
+		"level":     logging.SyslogSeverity(r.Level),
+	}
+
+	if i := strings.IndexByte(r.Message, '\n'); i >= 0 {
+		m["short_message"] = r.Message[:i]
+		m["full_message"] = r.Message
+	} else {
+		m["short_message"] = r.Message
+	}
+
+	for k, v := range r.Fields {
+		name := "_" + invalidFieldChars.ReplaceAllString(k, "_")
+		if name == "_id" {
+			name = "_id_"
+		}
+
+		m[name] = value(v)
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal gelf message, %w", err)
+	}
+
+	return b, nil
+}
+
+// value keeps numbers, additional fields are strings or numbers.
+func value(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return val
+	case string:
+		return val
+	case error:
+		return val.Error()
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// chunks splits msg into chunks: magic bytes 0x1e 0x0f, message ID
+// of 8 bytes, sequence number and count of chunks.
+func (s *Sink) chunks(msg []byte) ([][]byte, error) {
+	if len(msg) <= s.chunkSize {
+		return [][]byte{msg}, nil
+	}
+
+	size := s.chunkSize - chunkHeaderSize
+	count := (len(msg) + size - 1) / size
+
+	if count > maxChunks {
+		return nil, ErrTooManyChunks
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate message id, %w", err)
+	}
+
+	chunks := make([][]byte, 0, count)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(msg) {
+			end = len(msg)
+		}
+
+		chunk := make([]byte, 0, chunkHeaderSize+end-i*size)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*size:end]...)
+
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, nil
+}
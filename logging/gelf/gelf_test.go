@@ -0,0 +1,142 @@
+package gelf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imega/daemon/logging"
+)
+
+func record() logging.Record {
+	return logging.Record{
+		Time:    time.Unix(1666173600, 500000000),
+		Level:   logging.LevelWarn,
+		Message: "failed to ping mysql\n" + strings.Repeat("x", 200),
+		Fields: map[string]interface{}{
+			"channel":    "my-daemon",
+			"id":         "42",
+			"grpc code":  "OK",
+			"attempt":    2,
+			"request_id": "100500",
+		},
+	}
+}
+
+func check(t *testing.T, msg []byte) {
+	t.Helper()
+
+	actual := map[string]interface{}{}
+	if err := json.Unmarshal(msg, &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "test-host",
+		"timestamp":     1666173600.5,
+		"level":         float64(4),
+		"short_message": "failed to ping mysql",
+		"full_message":  record().Message,
+		"_channel":      "my-daemon",
+		"_id_":          "42",
+		"_grpc_code":    "OK",
+		"_attempt":      float64(2),
+		"_request_id":   "100500",
+	}
+
+	for k, v := range expected {
+		if actual[k] != v {
+			t.Errorf("field %s: expected %v, got %v", k, v, actual[k])
+		}
+	}
+}
+
+func TestSink_UDPChunks(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	s, err := New("udp", pc.LocalAddr().String(), WithHost("test-host"), WithChunkSize(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Send(record()); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		msg   []byte
+		count = -1
+		buf   = make([]byte, 1024)
+	)
+
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	for i := 0; i != count; i++ {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		chunk := buf[:n]
+		if !bytes.HasPrefix(chunk, []byte{0x1e, 0x0f}) || int(chunk[10]) != i || n > 100 {
+			t.Fatalf("unexpected chunk %d: %v", i, chunk[:12])
+		}
+
+		count = int(chunk[11])
+		msg = append(msg, chunk[12:]...)
+	}
+
+	check(t, msg)
+}
+
+func TestSink_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	msgs := make(chan []byte, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		msg, _ := bufio.NewReader(conn).ReadBytes(0)
+		msgs <- msg
+	}()
+
+	log, err := NewLogger(logging.Config{Channel: "my-daemon", Level: "info"}, "tcp", ln.Addr().String(), WithHost("test-host"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.WithFields(map[string]interface{}{"request_id": "100500"}).Infow("test", "attempt", 2)
+
+	select {
+	case msg := <-msgs:
+		actual := map[string]interface{}{}
+		if err := json.Unmarshal(bytes.TrimSuffix(msg, []byte{0}), &actual); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual["short_message"] != "test" || actual["_request_id"] != "100500" || actual["level"] != float64(6) || actual["_channel"] != "my-daemon" {
+			t.Errorf("unexpected message %v", actual)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package netconn is a connection of log sinks which redials
+// broken stream connections.
+package netconn
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	dialTimeout  = 5 * time.Second
+	writeTimeout = time.Second
+)
+
+// Conn is a connection to a log collector.
+type Conn struct {
+	network string
+	addr    string
+	timeout time.Duration
+
+	mx sync.Mutex
+	c  net.Conn
+}
+
+// Dial connects to addr, network is udp, tcp, unix or unixgram.
+func Dial(network, addr string) (*Conn, error) {
+	c := &Conn{network: network, addr: addr, timeout: writeTimeout}
+
+	if err := c.dial(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Stream reports whether the connection is a stream,
+// the messages must be framed.
+func (c *Conn) Stream() bool {
+	switch c.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	default:
+		return false
+	}
+}
+
+// Write writes the packets, a broken stream is redialed once.
+// A write which exceeds the write timeout fails and the connection
+// is closed, so a stalled collector doesn't block the logs.
+func (c *Conn) Write(packets ...[]byte) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	err := c.write(packets)
+	if err == nil || !c.Stream() {
+		return err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		c.close()
+
+		return err
+	}
+
+	c.close()
+
+	if err := c.dial(); err != nil {
+		return err
+	}
+
+	return c.write(packets)
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	return c.close()
+}
+
+func (c *Conn) dial() error {
+	conn, err := net.DialTimeout(c.network, c.addr, dialTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to dial %s %s, %w", c.network, c.addr, err)
+	}
+
+	c.c = conn
+
+	return nil
+}
+
+func (c *Conn) write(packets [][]byte) error {
+	if c.c == nil {
+		if err := c.dial(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.c.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return fmt.Errorf("failed to set write deadline, %w", err)
+	}
+
+	for _, p := range packets {
+		if _, err := c.c.Write(p); err != nil {
+			return fmt.Errorf("failed to write to %s %s, %w", c.network, c.addr, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Conn) close() error {
+	if c.c == nil {
+		return nil
+	}
+
+	err := c.c.Close()
+	c.c = nil
+
+	if err != nil {
+		return fmt.Errorf("failed to close connection, %w", err)
+	}
+
+	return nil
+}
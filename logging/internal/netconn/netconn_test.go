@@ -0,0 +1,48 @@
+package netconn
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestConn_Write_stalledCollector(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	c, err := Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	c.timeout = 50 * time.Millisecond
+
+	packet := make([]byte, 1<<20)
+	start := time.Now()
+
+	for err == nil && time.Since(start) < 5*time.Second {
+		err = c.Write(packet)
+	}
+
+	if err == nil {
+		t.Fatal("expected write timeout")
+	}
+
+	if c.c != nil {
+		t.Error("expected closed connection after timeout")
+	}
+
+	(<-accepted).Close()
+}
@@ -0,0 +1,182 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package journald sends logs to journald by its native protocol.
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/imega/daemon/logging"
+	"github.com/imega/daemon/logging/internal/netconn"
+)
+
+// DefaultSocket is the socket of journald.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+const (
+	maxFieldName   = 64
+	reservedPrefix = "FIELD_"
+)
+
+// reserved are the fields set by Sink, the fields of entry with
+// these names are prefixed by reservedPrefix, e.g. FIELD_MESSAGE.
+var reserved = map[string]struct{}{
+	"MESSAGE":           {},
+	"PRIORITY":          {},
+	"SYSLOG_IDENTIFIER": {},
+}
+
+// Sink is a logging.Sink which sends entries to journald. The fields
+// are upper-case journal fields, e.g. request_id is REQUEST_ID,
+// the channel is SYSLOG_IDENTIFIER as well. The fields named MESSAGE,
+// PRIORITY or SYSLOG_IDENTIFIER are prefixed, e.g. FIELD_MESSAGE.
+//
+// Entries which don't fit in a datagram are rejected by the socket.
+type Sink struct {
+	conn *netconn.Conn
+}
+
+// Option .
+type Option func(*options)
+
+type options struct {
+	socket string
+}
+
+// WithSocket changes the socket of journald, DefaultSocket by default.
+func WithSocket(path string) Option {
+	return func(o *options) {
+		o.socket = path
+	}
+}
+
+// New get a instance of Sink.
+func New(opts ...Option) (*Sink, error) {
+	o := &options{socket: DefaultSocket}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	conn, err := netconn.Dial("unixgram", o.socket)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sink{conn: conn}, nil
+}
+
+// NewLogger returns logging.Logger which sends to journald.
+func NewLogger(conf logging.Config, opts ...Option) (logging.Logger, error) {
+	s, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return logging.NewSinkLogger(s, conf), nil
+}
+
+// Send implements logging.Sink.
+func (s *Sink) Send(r logging.Record) error {
+	return s.conn.Write(Encode(r))
+}
+
+// Close closes the connection.
+func (s *Sink) Close() error {
+	return s.conn.Close()
+}
+
+// Encode returns the datagram of record. Values with a new line
+// are written as the field name, a new line, the length of value
+// in 64-bit little endian and the value.
+func Encode(r logging.Record) []byte {
+	buf := &bytes.Buffer{}
+
+	writeField(buf, "MESSAGE", r.Message)
+	writeField(buf, "PRIORITY", strconv.Itoa(logging.SyslogSeverity(r.Level)))
+
+	if ch, ok := r.Fields[logging.ChannelKey].(string); ok && ch != "" {
+		writeField(buf, "SYSLOG_IDENTIFIER", ch)
+	}
+
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name := fieldName(k)
+		if name == "" {
+			continue
+		}
+
+		writeField(buf, name, fmt.Sprint(r.Fields[k]))
+	}
+
+	return buf.Bytes()
+}
+
+func writeField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+
+		return
+	}
+
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// fieldName returns the journal field name of key, it consists of
+// upper-case letters, digits and underscores and doesn't start with
+// an underscore or a digit. It returns empty name if key is invalid,
+// a reserved name is prefixed.
+func fieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+
+	name = strings.TrimLeft(name, "_0123456789")
+
+	if _, ok := reserved[name]; ok {
+		name = reservedPrefix + name
+	}
+
+	if len(name) > maxFieldName {
+		name = name[:maxFieldName]
+	}
+
+	return name
+}
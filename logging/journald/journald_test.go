@@ -0,0 +1,80 @@
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/imega/daemon/logging"
+)
+
+func TestSink(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	log, err := NewLogger(logging.Config{Channel: "my-daemon", BuildID: "1"}, WithSocket(socket))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.WithFields(map[string]interface{}{
+		"grpc.code": "Unknown",
+		"_hidden":   "x",
+	}).WithError(errors.New("line 1\nline 2")).Errorf("failed")
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	buf := make([]byte, 4096)
+
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, uint64(len("line 1\nline 2")))
+
+	expected := "MESSAGE=failed\n" +
+		"PRIORITY=3\n" +
+		"SYSLOG_IDENTIFIER=my-daemon\n" +
+		"HIDDEN=x\n" +
+		"BUILD_ID=1\n" +
+		"CHANNEL=my-daemon\n" +
+		"ERROR\n" + string(size) + "line 1\nline 2\n" +
+		"GRPC_CODE=Unknown\n"
+
+	if !bytes.Equal(buf[:n], []byte(expected)) {
+		t.Errorf("expected\n%q\ngot\n%q", expected, buf[:n])
+	}
+}
+
+func TestEncode_reservedFields(t *testing.T) {
+	got := Encode(logging.Record{
+		Level:   logging.LevelInfo,
+		Message: "started",
+		Fields: map[string]interface{}{
+			"message":           "user message",
+			"priority":          "high",
+			"syslog_identifier": "other",
+		},
+	})
+
+	expected := "MESSAGE=started\n" +
+		"PRIORITY=6\n" +
+		"FIELD_MESSAGE=user message\n" +
+		"FIELD_PRIORITY=high\n" +
+		"FIELD_SYSLOG_IDENTIFIER=other\n"
+
+	if string(got) != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, got)
+	}
+}
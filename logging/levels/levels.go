@@ -0,0 +1,176 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package levels
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/imega/daemon"
+)
+
+// Levels keeps the level of loggers and the overrides of components.
+// Its WatcherConfigFunc watches prefix/log/level and
+// prefix/log/components/<component>, PREFIX_LOG_LEVEL and
+// PREFIX_LOG_COMPONENTS_<COMPONENT> in env.
+type Levels struct {
+	prefix string
+	def    string
+
+	mx         sync.RWMutex
+	level      string
+	components map[string]string
+	subs       map[int]func()
+	nextID     int
+
+	daemon.WatcherConfigFunc
+}
+
+const mainKey = "log"
+
+// New returns Levels with the level by default.
+func New(prefix, level string) *Levels {
+	l := &Levels{
+		prefix:     prefix,
+		def:        level,
+		level:      level,
+		components: make(map[string]string),
+		subs:       make(map[int]func()),
+	}
+
+	l.WatcherConfigFunc = func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:  prefix,
+			MainKey: mainKey,
+			Keys:    []string{"level", "components"},
+			Schema: daemon.Schema{
+				{
+					Name:        "level",
+					Type:        daemon.TypeString,
+					Default:     level,
+					Description: "Log level.",
+				},
+				{
+					Name:        "components",
+					Type:        daemon.TypeString,
+					Description: "Log level of component, one key per component components/<name>.",
+				},
+			},
+			ApplyChanges: l.apply,
+		}
+	}
+
+	return l
+}
+
+// Level returns the level of component. The main level is returned
+// if component has no override.
+func (l *Levels) Level(component string) string {
+	l.mx.RLock()
+	defer l.mx.RUnlock()
+
+	if lvl, ok := l.components[component]; ok && component != "" {
+		return lvl
+	}
+
+	return l.level
+}
+
+// Set sets the main level. An empty level restores the level by default.
+func (l *Levels) Set(level string) {
+	l.mx.Lock()
+
+	if level == "" {
+		level = l.def
+	}
+
+	l.level = level
+
+	l.mx.Unlock()
+
+	l.notify()
+}
+
+// SetComponent sets the level of component. An empty level
+// removes the override.
+func (l *Levels) SetComponent(component, level string) {
+	l.mx.Lock()
+
+	if level == "" {
+		delete(l.components, component)
+	} else {
+		l.components[component] = level
+	}
+
+	l.mx.Unlock()
+
+	l.notify()
+}
+
+// Subscribe calls fn on every change of levels.
+// It returns a function to unsubscribe.
+func (l *Levels) Subscribe(fn func()) func() {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	id := l.nextID
+	l.nextID++
+	l.subs[id] = fn
+
+	return func() {
+		l.mx.Lock()
+		defer l.mx.Unlock()
+
+		delete(l.subs, id)
+	}
+}
+
+func (l *Levels) notify() {
+	l.mx.RLock()
+
+	subs := make([]func(), 0, len(l.subs))
+	for _, fn := range l.subs {
+		subs = append(subs, fn)
+	}
+
+	l.mx.RUnlock()
+
+	for _, fn := range subs {
+		fn()
+	}
+}
+
+func (l *Levels) apply(cs daemon.ChangeSet) {
+	base := l.prefix + "/" + mainKey + "/"
+	components := make(map[string]string)
+	level := l.def
+
+	for k, v := range cs.Conf {
+		switch {
+		case k == base+"level":
+			level = strings.ToLower(strings.TrimSpace(v))
+
+		case strings.HasPrefix(k, base+"components/"):
+			components[k[len(base+"components/"):]] = strings.ToLower(strings.TrimSpace(v))
+		}
+	}
+
+	l.mx.Lock()
+	l.level = level
+	l.components = components
+	l.mx.Unlock()
+
+	l.notify()
+}
@@ -0,0 +1,50 @@
+package levels
+
+import (
+	"testing"
+
+	"github.com/imega/daemon"
+)
+
+func TestLevels_Apply(t *testing.T) {
+	l := New("my-daemon", "info")
+	apply := l.WatcherConfigFunc().ApplyChanges
+
+	notified := 0
+	unsubscribe := l.Subscribe(func() { notified++ })
+
+	conf := map[string]string{
+		"my-daemon/log/level":           "ERROR",
+		"my-daemon/log/components/grpc": "debug",
+	}
+	apply(daemon.NewChangeSet(conf, nil))
+
+	if got := l.Level(""); got != "error" {
+		t.Errorf("Level() = %s, want error", got)
+	}
+
+	if got := l.Level("grpc"); got != "debug" {
+		t.Errorf("Level(grpc) = %s, want debug", got)
+	}
+
+	if got := l.Level("mysql"); got != "error" {
+		t.Errorf("Level(mysql) = %s, want error", got)
+	}
+
+	apply(daemon.NewChangeSet(nil, conf))
+
+	if got := l.Level("grpc"); got != "info" {
+		t.Errorf("Level(grpc) after removing = %s, want info", got)
+	}
+
+	unsubscribe()
+	l.SetComponent("grpc", "warn")
+
+	if notified != 2 {
+		t.Errorf("Subscribe() notified %d times, want 2", notified)
+	}
+
+	if got := l.Level("grpc"); got != "warn" {
+		t.Errorf("Level(grpc) = %s, want warn", got)
+	}
+}
@@ -14,14 +14,56 @@
 
 package logging
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// ErrorKey is the field name of error added by WithError.
+	ErrorKey = "error"
+
+	// RequestIDKey is the field name of request ID.
+	RequestIDKey = "request_id"
+)
+
+// Names of levels.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
 
 // Logger is an interface for logger.
 type Logger interface {
+	BasicLogger
+	Warnf(format string, args ...interface{})
+
+	// Infow logs a message with some key-value pairs,
+	// e.g. log.Infow("connected", "host", host, "attempt", 2).
+	Infow(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	Debugw(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+
+	WithFields(map[string]interface{}) Logger
+	WithError(err error) Logger
+
+	// WithContext returns a logger with the fields stored in ctx
+	// by ContextWithFields.
+	WithContext(ctx context.Context) Logger
+}
+
+// BasicLogger is the leveled printf part of Logger. Implementations
+// written before Logger had Warnf and key-value methods can be adapted
+// by Upgrade.
+type BasicLogger interface {
 	Infof(format string, args ...interface{})
 	Errorf(format string, args ...interface{})
 	Debugf(format string, args ...interface{})
-	WithFields(map[string]interface{}) Logger
 }
 
 // Config is a configuration logger.
@@ -29,6 +71,9 @@ type Config struct {
 	Channel string
 	BuildID string
 	Level   string
+
+	// File is the path of log file, see filesink.FromConfig.
+	File string
 }
 
 func ContextWithLogger(ctx context.Context, log Logger) context.Context {
@@ -39,6 +84,28 @@ func ContextWithLogger(ctx context.Context, log Logger) context.Context {
 	return context.WithValue(ctx, key{}, log)
 }
 
+// ReplaceLogger returns a copy of ctx with log, unlike ContextWithLogger
+// it replaces the logger stored in ctx.
+func ReplaceLogger(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, key{}, log)
+}
+
+// Enrich returns a copy of ctx with the logger of ctx extended by fields,
+// so GetLogger returns the enriched logger. The fields are stored by
+// ContextWithFields as well, enrichment may be nested.
+func Enrich(ctx context.Context, fields map[string]interface{}) context.Context {
+	ctx = ContextWithFields(ctx, fields)
+
+	return ReplaceLogger(ctx, GetLogger(ctx).WithFields(fields))
+}
+
+// RequestID returns the request ID stored in ctx by Enrich.
+func RequestID(ctx context.Context) string {
+	id, _ := FieldsFromContext(ctx)[RequestIDKey].(string)
+
+	return id
+}
+
 func GetLogger(ctx context.Context) Logger {
 	val, ok := ctx.Value(key{}).(Logger)
 	if !ok {
@@ -48,6 +115,40 @@ func GetLogger(ctx context.Context) Logger {
 	return val
 }
 
+// Severity returns the rank of level, the higher the more severe.
+// Unknown levels are more severe than error.
+func Severity(level string) int {
+	switch strings.ToLower(level) {
+	case LevelDebug, "trace":
+		return 0
+	case LevelInfo:
+		return 1
+	case LevelWarn, "warning":
+		return 2
+	case LevelError:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// Enabler is implemented by the loggers which can report whether
+// a level is enabled. It lets bridges of third-party libraries skip
+// the work for the levels which are dropped.
+type Enabler interface {
+	Enabled(level string) bool
+}
+
+// Enabled reports whether l logs the level, a logger which
+// doesn't implement Enabler is considered to log all levels.
+func Enabled(l Logger, level string) bool {
+	if e, ok := l.(Enabler); ok {
+		return e.Enabled(level)
+	}
+
+	return true
+}
+
 type key struct{}
 
 func GetNoopLog() Logger {
@@ -59,5 +160,173 @@ type noopLog struct{}
 func (nl *noopLog) Infof(string, ...interface{})  {}
 func (nl *noopLog) Errorf(string, ...interface{}) {}
 func (nl *noopLog) Debugf(string, ...interface{}) {}
+func (nl *noopLog) Warnf(string, ...interface{})  {}
+
+func (nl *noopLog) Infow(string, ...interface{})  {}
+func (nl *noopLog) Errorw(string, ...interface{}) {}
+func (nl *noopLog) Debugw(string, ...interface{}) {}
+func (nl *noopLog) Warnw(string, ...interface{})  {}
 
 func (nl *noopLog) WithFields(map[string]interface{}) Logger { return nl }
+func (nl *noopLog) WithError(error) Logger                   { return nl }
+func (nl *noopLog) WithContext(context.Context) Logger       { return nl }
+func (nl *noopLog) Enabled(string) bool                      { return false }
+
+type fieldsKey struct{}
+
+// ContextWithFields returns a copy of ctx with fields, they are merged
+// with the fields already stored in ctx. Logger.WithContext adds them
+// to the logger.
+func ContextWithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	merged := make(map[string]interface{}, len(fields))
+
+	for k, v := range FieldsFromContext(ctx) {
+		merged[k] = v
+	}
+
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFromContext returns the fields stored by ContextWithFields.
+func FieldsFromContext(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsKey{}).(map[string]interface{})
+
+	return fields
+}
+
+// Fields converts key-value pairs to fields. A key which is not a string
+// is formatted by fmt, a value without a key is stored as "!BADKEY".
+func Fields(keysAndValues ...interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(keysAndValues)/2)
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			fields["!BADKEY"] = keysAndValues[i]
+
+			break
+		}
+
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+
+		fields[key] = keysAndValues[i+1]
+	}
+
+	return fields
+}
+
+// Upgrade adapts a BasicLogger to Logger. Warnings are logged at info
+// level with the "warning: " prefix, fields are appended to the message
+// as key=value. If l is a Logger already it is returned as is.
+func Upgrade(l BasicLogger) Logger {
+	if log, ok := l.(Logger); ok {
+		return log
+	}
+
+	return &basicLog{wrapped: l}
+}
+
+type basicLog struct {
+	wrapped BasicLogger
+	fields  map[string]interface{}
+}
+
+func (l *basicLog) Infof(format string, args ...interface{}) {
+	l.wrapped.Infof("%s", l.format(fmt.Sprintf(format, args...), nil))
+}
+
+func (l *basicLog) Errorf(format string, args ...interface{}) {
+	l.wrapped.Errorf("%s", l.format(fmt.Sprintf(format, args...), nil))
+}
+
+func (l *basicLog) Debugf(format string, args ...interface{}) {
+	l.wrapped.Debugf("%s", l.format(fmt.Sprintf(format, args...), nil))
+}
+
+func (l *basicLog) Warnf(format string, args ...interface{}) {
+	l.wrapped.Infof("warning: %s", l.format(fmt.Sprintf(format, args...), nil))
+}
+
+func (l *basicLog) Infow(msg string, keysAndValues ...interface{}) {
+	l.wrapped.Infof("%s", l.format(msg, Fields(keysAndValues...)))
+}
+
+func (l *basicLog) Errorw(msg string, keysAndValues ...interface{}) {
+	l.wrapped.Errorf("%s", l.format(msg, Fields(keysAndValues...)))
+}
+
+func (l *basicLog) Debugw(msg string, keysAndValues ...interface{}) {
+	l.wrapped.Debugf("%s", l.format(msg, Fields(keysAndValues...)))
+}
+
+func (l *basicLog) Warnw(msg string, keysAndValues ...interface{}) {
+	l.wrapped.Infof("warning: %s", l.format(msg, Fields(keysAndValues...)))
+}
+
+func (l *basicLog) WithFields(fields map[string]interface{}) Logger {
+	merged := make(map[string]interface{}, len(l.fields)+len(fields))
+
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return &basicLog{wrapped: l.wrapped, fields: merged}
+}
+
+func (l *basicLog) WithError(err error) Logger {
+	return l.WithFields(map[string]interface{}{ErrorKey: err})
+}
+
+func (l *basicLog) WithContext(ctx context.Context) Logger {
+	return l.WithFields(FieldsFromContext(ctx))
+}
+
+func (l *basicLog) format(msg string, extra map[string]interface{}) string {
+	fields := l.fields
+	if len(extra) > 0 {
+		fields = make(map[string]interface{}, len(l.fields)+len(extra))
+
+		for k, v := range l.fields {
+			fields[k] = v
+		}
+
+		for k, v := range extra {
+			fields[k] = v
+		}
+	}
+
+	if len(fields) == 0 {
+		return msg
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var sb strings.Builder
+
+	sb.WriteString(msg)
+
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " %s=%v", k, fields[k])
+	}
+
+	return sb.String()
+}
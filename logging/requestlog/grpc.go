@@ -0,0 +1,72 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requestlog
+
+import (
+	"context"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryServerInterceptor stores the request-scoped logger in the context
+// of unary calls. The request ID is sent in the header of response.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	options := evaluateOptions(opts)
+
+	return func(
+		ctx context.Context,
+		req interface{},
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		return handler(options.enrichIncoming(ctx), req)
+	}
+}
+
+// StreamServerInterceptor stores the request-scoped logger in the context
+// of streams. The request ID is sent in the header of response.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	options := evaluateOptions(opts)
+
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		wrapped := grpc_middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = options.enrichIncoming(ss.Context())
+
+		return handler(srv, wrapped)
+	}
+}
+
+func (o *options) enrichIncoming(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	ctx, id := o.enrich(ctx, func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+
+		return ""
+	})
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(o.requestIDHeader, id))
+
+	return ctx
+}
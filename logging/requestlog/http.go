@@ -0,0 +1,46 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requestlog
+
+import "net/http"
+
+// Middleware is a server-side http ware which stores the request-scoped
+// logger in the context. The request ID is returned in the header
+// of response.
+//
+// # Example
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+//	    logging.GetLogger(r.Context()).Infof("hello")
+//	})
+//	h := requestlog.Middleware(
+//	    mux,
+//	    requestlog.WithLogger(log),
+//	    requestlog.HeadersToFields(map[string]string{
+//	        "X-Site-ID": "site_id",
+//	    }),
+//	)
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	options := evaluateOptions(opts)
+
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		ctx, id := options.enrich(req.Context(), req.Header.Get)
+
+		resp.Header().Set(options.requestIDHeader, id)
+
+		next.ServeHTTP(resp, req.WithContext(ctx))
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requestlog
+
+import "github.com/imega/daemon/logging"
+
+// DefaultRequestIDHeader is the header of request ID.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+type options struct {
+	log             logging.Logger
+	requestIDHeader string
+	headers         map[string]string
+	newID           func() string
+}
+
+// Option .
+type Option func(*options)
+
+// WithLogger sets the logger which is enriched, by default
+// it is the logger of request context.
+func WithLogger(l logging.Logger) Option {
+	return func(o *options) {
+		o.log = l
+	}
+}
+
+// RequestIDHeader changes the header of request ID,
+// X-Request-ID by default.
+func RequestIDHeader(name string) Option {
+	return func(o *options) {
+		o.requestIDHeader = name
+	}
+}
+
+// HeadersToFields is a map relationships between headers and fields.
+// Names of gRPC metadata are lower-case headers.
+func HeadersToFields(v map[string]string) Option {
+	return func(o *options) {
+		o.headers = v
+	}
+}
+
+// IDGenerator changes the generator of request ID for the requests
+// without it, by default it is 16 random bytes in hex.
+func IDGenerator(fn func() string) Option {
+	return func(o *options) {
+		o.newID = fn
+	}
+}
+
+func evaluateOptions(opts []Option) *options {
+	o := &options{
+		requestIDHeader: DefaultRequestIDHeader,
+		newID:           newID,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
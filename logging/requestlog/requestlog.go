@@ -0,0 +1,89 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package requestlog stores a request-scoped logger in the context
+// of HTTP and gRPC requests. The logger has the request ID, the trace
+// and the configured headers as fields, handlers get it by
+// logging.GetLogger.
+package requestlog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/imega/daemon/logging"
+)
+
+const (
+	// TraceIDKey is the field name of trace ID.
+	TraceIDKey = "trace_id"
+
+	// SpanIDKey is the field name of parent span ID.
+	SpanIDKey = "span_id"
+
+	traceparentHeader = "traceparent"
+	maxRequestIDLen   = 128
+)
+
+// enrich stores in ctx the logger with fields of request,
+// get returns the value of header. It returns the request ID.
+func (o *options) enrich(ctx context.Context, get func(string) string) (context.Context, string) {
+	id := get(o.requestIDHeader)
+	if id == "" || len(id) > maxRequestIDLen {
+		id = o.newID()
+	}
+
+	fields := map[string]interface{}{
+		logging.RequestIDKey: id,
+	}
+
+	if traceID, spanID, ok := parseTraceparent(get(traceparentHeader)); ok {
+		fields[TraceIDKey] = traceID
+		fields[SpanIDKey] = spanID
+	}
+
+	for header, field := range o.headers {
+		if v := get(header); v != "" {
+			fields[field] = v
+		}
+	}
+
+	if o.log != nil {
+		ctx = logging.ReplaceLogger(ctx, o.log.WithContext(ctx))
+	}
+
+	return logging.Enrich(ctx, fields), id
+}
+
+// parseTraceparent returns trace and parent IDs of W3C traceparent header,
+// e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func parseTraceparent(v string) (string, string, bool) {
+	parts := strings.Split(v, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,111 @@
+package requestlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/imega/daemon/logging"
+	"github.com/imega/daemon/logging/requestlog"
+	"github.com/imega/daemon/logging/wrapzerolog"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+
+	actual := map[string]interface{}{}
+	err := json.NewDecoder(buf).Decode(&actual)
+	require.NoError(t, err)
+
+	return actual
+}
+
+func TestMiddleware(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	log := wrapzerolog.New(zerolog.New(buf))
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := logging.Enrich(r.Context(), map[string]interface{}{"user": "bob"})
+		logging.GetLogger(ctx).Infof("test")
+	})
+
+	h := requestlog.Middleware(
+		inner,
+		requestlog.WithLogger(log),
+		requestlog.HeadersToFields(map[string]string{"X-Site-ID": "site_id"}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "42")
+	req.Header.Set("X-Site-ID", "100500")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	expected := map[string]interface{}{
+		"level":      "info",
+		"request_id": "42",
+		"site_id":    "100500",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+		"user":       "bob",
+		"message":    "test",
+	}
+	assert.Equal(t, expected, decode(t, buf))
+	assert.Equal(t, "42", rec.Header().Get("X-Request-ID"))
+}
+
+func TestMiddleware_generatesRequestID(t *testing.T) {
+	var id string
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = logging.RequestID(r.Context())
+	})
+
+	h := requestlog.Middleware(inner, requestlog.IDGenerator(func() string { return "generated" }))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, "generated", id)
+	assert.Equal(t, "generated", rec.Header().Get("X-Request-ID"))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	log := wrapzerolog.New(zerolog.New(buf))
+
+	interceptor := requestlog.UnaryServerInterceptor(
+		requestlog.WithLogger(log),
+		requestlog.HeadersToFields(map[string]string{"x-site-id": "site_id"}),
+	)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"x-request-id", "42",
+		"x-site-id", "100500",
+	))
+
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		logging.GetLogger(ctx).Infof("test")
+
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	expected := map[string]interface{}{
+		"level":      "info",
+		"request_id": "42",
+		"site_id":    "100500",
+		"message":    "test",
+	}
+	assert.Equal(t, expected, decode(t, buf))
+}
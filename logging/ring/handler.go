@@ -0,0 +1,223 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ring
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imega/daemon/logging"
+)
+
+// Handler returns an http.Handler which serves the kept entries as JSON.
+// Query parameters filter the entries:
+//
+//   - level, the minimal level, debug, info, warn or error;
+//   - q, a substring of message, case-insensitive;
+//   - since, entries newer than a duration (5m) or RFC 3339 time;
+//   - limit, the number of the newest entries;
+//   - follow, streams new entries as server-sent events,
+//     the stream resumes after the Last-Event-ID header;
+//   - any other parameter is a value of field, e.g. request_id=42.
+//
+// It is mounted next to the health handler on an admin port.
+//
+// # Example
+//
+//	log := ring.New(wrapzerolog.New(zl), 1000)
+//	mux := http.NewServeMux()
+//	mux.Handle("/healthcheck", health.Handler(health.WithHealthCheckFuncs(m.HealthCheckFunc)))
+//	mux.Handle("/logs", log.Handler())
+func (l *Logger) Handler() http.Handler {
+	return http.HandlerFunc(l.serveHTTP)
+}
+
+type filter struct {
+	level  int
+	q      string
+	since  time.Time
+	limit  int
+	fields map[string]string
+	after  uint64
+}
+
+func parseFilter(r *http.Request, now time.Time) (*filter, error) {
+	f := &filter{fields: make(map[string]string)}
+
+	for k, vs := range r.URL.Query() {
+		v := vs[0]
+
+		switch k {
+		case "level":
+			f.level = logging.Severity(v)
+			if f.level > logging.Severity(logging.LevelError) {
+				return nil, fmt.Errorf("failed to parse level, unknown level %q", v)
+			}
+		case "q":
+			f.q = strings.ToLower(v)
+		case "since":
+			if d, err := time.ParseDuration(v); err == nil {
+				f.since = now.Add(-d)
+
+				break
+			}
+
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse since, %w", err)
+			}
+
+			f.since = t
+		case "limit":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse limit, %w", err)
+			}
+
+			f.limit = n
+		case "follow":
+		default:
+			f.fields[k] = v
+		}
+	}
+
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		f.after, _ = strconv.ParseUint(id, 10, 64)
+	}
+
+	return f, nil
+}
+
+func (f *filter) match(e Entry) bool {
+	if e.Seq <= f.after || logging.Severity(e.Level) < f.level || e.Time.Before(f.since) {
+		return false
+	}
+
+	if f.q != "" && !strings.Contains(strings.ToLower(e.Message), f.q) {
+		return false
+	}
+
+	for k, v := range f.fields {
+		val, ok := e.Fields[k]
+		if !ok || fmt.Sprint(val) != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *filter) apply(entries []Entry) []Entry {
+	res := make([]Entry, 0, len(entries))
+
+	for _, e := range entries {
+		if f.match(e) {
+			res = append(res, e)
+		}
+	}
+
+	if f.limit > 0 && len(res) > f.limit {
+		res = res[len(res)-f.limit:]
+	}
+
+	return res
+}
+
+func (l *Logger) serveHTTP(resp http.ResponseWriter, r *http.Request) {
+	f, err := parseFilter(r, l.buf.now())
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if _, ok := r.URL.Query()["follow"]; ok {
+		l.follow(resp, r, f)
+
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(resp).Encode(f.apply(l.buf.snapshot())); err != nil {
+		l.log.WithError(err).Errorf("failed to encode log entries")
+	}
+}
+
+func (l *Logger) follow(resp http.ResponseWriter, r *http.Request, f *filter) {
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		http.Error(resp, "streaming unsupported", http.StatusInternalServerError)
+
+		return
+	}
+
+	ch, unsubscribe := l.buf.subscribe()
+	defer unsubscribe()
+
+	resp.Header().Set("Content-Type", "text/event-stream")
+	resp.Header().Set("Cache-Control", "no-cache")
+	resp.WriteHeader(http.StatusOK)
+
+	for _, e := range f.apply(l.buf.snapshot()) {
+		if !writeEvent(resp, e) {
+			return
+		}
+
+		f.after = e.Seq
+	}
+
+	flusher.Flush()
+
+	f.limit = 0
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			if !f.match(e) {
+				continue
+			}
+
+			if !writeEvent(resp, e) {
+				return
+			}
+
+			f.after = e.Seq
+
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(resp http.ResponseWriter, e Entry) bool {
+	data, err := json.Marshal(e)
+	if err != nil {
+		e.Fields = map[string]interface{}{"fields": fmt.Sprint(e.Fields)}
+
+		if data, err = json.Marshal(e); err != nil {
+			return true
+		}
+	}
+
+	_, err = fmt.Fprintf(resp, "id: %d\ndata: %s\n\n", e.Seq, data)
+
+	return err == nil
+}
@@ -0,0 +1,227 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ring keeps the last log entries in memory and serves them
+// over HTTP.
+package ring
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/imega/daemon/logging"
+)
+
+const defaultSize = 1000
+
+// Entry is a log entry.
+type Entry struct {
+	Seq     uint64                 `json:"seq"`
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Logger is a logging.Logger which keeps the last entries logged
+// through it, the entries are passed to the wrapped logger as well.
+// Only the levels enabled in the wrapped logger are kept.
+type Logger struct {
+	log    logging.Logger
+	fields map[string]interface{}
+	buf    *buffer
+}
+
+type buffer struct {
+	mx      sync.Mutex
+	entries []Entry
+	next    int
+	seq     uint64
+	subs    map[chan Entry]struct{}
+	now     func() time.Time
+}
+
+// New get a instance of Logger which keeps size entries,
+// 1000 if size is not positive.
+func New(log logging.Logger, size int) *Logger {
+	if size <= 0 {
+		size = defaultSize
+	}
+
+	return &Logger{
+		log: log,
+		buf: &buffer{
+			entries: make([]Entry, 0, size),
+			subs:    make(map[chan Entry]struct{}),
+			now:     time.Now,
+		},
+	}
+}
+
+// Entries returns the kept entries from the oldest.
+func (l *Logger) Entries() []Entry {
+	return l.buf.snapshot()
+}
+
+func (b *buffer) add(e Entry) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.seq++
+	e.Seq = b.seq
+	e.Time = b.now()
+
+	if len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, e)
+	} else {
+		b.entries[b.next] = e
+		b.next = (b.next + 1) % len(b.entries)
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (b *buffer) snapshot() []Entry {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	res := make([]Entry, 0, len(b.entries))
+	res = append(res, b.entries[b.next:]...)
+
+	return append(res, b.entries[:b.next]...)
+}
+
+// subscribe returns a channel of new entries, slow readers lose entries.
+func (b *buffer) subscribe() (<-chan Entry, func()) {
+	ch := make(chan Entry, 64)
+
+	b.mx.Lock()
+	b.subs[ch] = struct{}{}
+	b.mx.Unlock()
+
+	return ch, func() {
+		b.mx.Lock()
+		delete(b.subs, ch)
+		b.mx.Unlock()
+	}
+}
+
+func (l *Logger) record(level, msg string, extra map[string]interface{}) {
+	if !logging.Enabled(l.log, level) {
+		return
+	}
+
+	var fields map[string]interface{}
+
+	if len(l.fields)+len(extra) > 0 {
+		fields = make(map[string]interface{}, len(l.fields)+len(extra))
+
+		for k, v := range l.fields {
+			fields[k] = v
+		}
+
+		for k, v := range extra {
+			fields[k] = value(v)
+		}
+	}
+
+	l.buf.add(Entry{Level: level, Message: msg, Fields: fields})
+}
+
+func (l *Logger) with(log logging.Logger, fields map[string]interface{}) *Logger {
+	merged := make(map[string]interface{}, len(l.fields)+len(fields))
+
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+
+	for k, v := range fields {
+		merged[k] = value(v)
+	}
+
+	return &Logger{log: log, fields: merged, buf: l.buf}
+}
+
+// value keeps the text of errors, they are not marshaled to JSON.
+func value(v interface{}) interface{} {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+
+	return v
+}
+
+// Enabled implements logging.Enabler.
+func (l *Logger) Enabled(level string) bool {
+	return logging.Enabled(l.log, level)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.record(logging.LevelInfo, fmt.Sprintf(format, args...), nil)
+	l.log.Infof(format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.record(logging.LevelError, fmt.Sprintf(format, args...), nil)
+	l.log.Errorf(format, args...)
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.record(logging.LevelDebug, fmt.Sprintf(format, args...), nil)
+	l.log.Debugf(format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.record(logging.LevelWarn, fmt.Sprintf(format, args...), nil)
+	l.log.Warnf(format, args...)
+}
+
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.record(logging.LevelInfo, msg, logging.Fields(keysAndValues...))
+	l.log.Infow(msg, keysAndValues...)
+}
+
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.record(logging.LevelError, msg, logging.Fields(keysAndValues...))
+	l.log.Errorw(msg, keysAndValues...)
+}
+
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.record(logging.LevelDebug, msg, logging.Fields(keysAndValues...))
+	l.log.Debugw(msg, keysAndValues...)
+}
+
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.record(logging.LevelWarn, msg, logging.Fields(keysAndValues...))
+	l.log.Warnw(msg, keysAndValues...)
+}
+
+func (l *Logger) WithFields(fields map[string]interface{}) logging.Logger {
+	return l.with(l.log.WithFields(fields), fields)
+}
+
+func (l *Logger) WithError(err error) logging.Logger {
+	return l.with(l.log.WithError(err), map[string]interface{}{logging.ErrorKey: err})
+}
+
+func (l *Logger) WithContext(ctx context.Context) logging.Logger {
+	return l.with(l.log.WithContext(ctx), logging.FieldsFromContext(ctx))
+}
@@ -0,0 +1,146 @@
+package ring
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/imega/daemon/logging"
+)
+
+func messages(entries []Entry) []string {
+	res := make([]string, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, e.Message)
+	}
+
+	return res
+}
+
+func TestLogger_Entries(t *testing.T) {
+	log := New(logging.Upgrade(&nopBasic{}), 2)
+
+	log.Infof("first")
+	log.WithError(errors.New("boom")).Errorf("second")
+	log.Warnw("third", "attempt", 3)
+
+	entries := log.Entries()
+
+	if got := strings.Join(messages(entries), ","); got != "second,third" {
+		t.Fatalf("unexpected entries %s", got)
+	}
+
+	if entries[0].Fields["error"] != "boom" || entries[0].Level != "error" || entries[0].Seq != 2 {
+		t.Errorf("unexpected entry %+v", entries[0])
+	}
+}
+
+func TestLogger_Handler(t *testing.T) {
+	log := New(logging.Upgrade(&nopBasic{}), 10)
+
+	log.Debugf("debug")
+	log.WithFields(map[string]interface{}{"request_id": "42"}).Warnf("Slow query")
+	log.Warnf("slow query")
+	log.Errorf("failed to ping mysql")
+
+	srv := httptest.NewServer(log.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?level=warn&q=SLOW&request_id=42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var entries []Entry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(messages(entries), ","); got != "Slow query" {
+		t.Errorf("unexpected entries %s", got)
+	}
+}
+
+func TestLogger_Handler_unknownLevel(t *testing.T) {
+	log := New(logging.Upgrade(&nopBasic{}), 10)
+	log.Errorf("failed to ping mysql")
+
+	srv := httptest.NewServer(log.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?level=warnn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestLogger_HandlerFollow(t *testing.T) {
+	log := New(logging.Upgrade(&nopBasic{}), 10)
+	log.Infof("first")
+	log.Errorf("second")
+
+	srv := httptest.NewServer(log.Handler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?follow&level=error", nil)
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %s", ct)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	next := func() Entry {
+		for sc.Scan() {
+			if data := strings.TrimPrefix(sc.Text(), "data: "); data != sc.Text() {
+				var e Entry
+				if err := json.Unmarshal([]byte(data), &e); err != nil {
+					t.Fatal(err)
+				}
+
+				return e
+			}
+		}
+
+		t.Fatalf("stream is closed, %v", sc.Err())
+
+		return Entry{}
+	}
+
+	if e := next(); e.Message != "second" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	log.Infof("skipped")
+	log.Errorf("third")
+
+	if e := next(); e.Message != "third" || e.Seq != 4 {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+}
+
+type nopBasic struct{}
+
+func (nopBasic) Infof(string, ...interface{})  {}
+func (nopBasic) Errorf(string, ...interface{}) {}
+func (nopBasic) Debugf(string, ...interface{}) {}
@@ -0,0 +1,424 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sampling limits the rate of repeated log messages.
+package sampling
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/logging"
+)
+
+const (
+	mainKey = "log-sampling"
+
+	defaultBurst           = 10
+	defaultRate            = 1
+	defaultPassLevel       = PassNone
+	defaultSummaryInterval = time.Minute
+
+	// PassNone is the PassLevel which limits all levels.
+	PassNone = "none"
+)
+
+// Config is the limits of Sampler.
+type Config struct {
+	// Burst is the number of messages of template logged before limiting,
+	// zero disables limiting.
+	Burst int
+
+	// Rate is the number of messages of template per second
+	// logged after Burst is spent.
+	Rate float64
+
+	// PassLevel is the level from which messages are never limited,
+	// PassNone limits all levels including error, it is by default.
+	PassLevel string
+
+	// SummaryInterval is the period of "suppressed N messages" summaries.
+	SummaryInterval time.Duration
+}
+
+// Sampler is a logging.Logger which limits the rate of messages per
+// template, the template is the format or the message of key-value
+// methods. Its WatcherConfigFunc watches prefix/log-sampling/burst,
+// rate, pass-level and summary-interval.
+type Sampler struct {
+	log   logging.Logger
+	state *state
+
+	daemon.WatcherConfigFunc
+	daemon.ShutdownFunc
+}
+
+// Option .
+type Option func(*Config)
+
+// WithBurst sets Config.Burst by default.
+func WithBurst(n int) Option {
+	return func(c *Config) {
+		c.Burst = n
+	}
+}
+
+// WithRate sets Config.Rate by default.
+func WithRate(perSecond float64) Option {
+	return func(c *Config) {
+		c.Rate = perSecond
+	}
+}
+
+// WithPassLevel sets Config.PassLevel by default.
+func WithPassLevel(level string) Option {
+	return func(c *Config) {
+		c.PassLevel = level
+	}
+}
+
+// WithSummaryInterval sets Config.SummaryInterval by default.
+func WithSummaryInterval(d time.Duration) Option {
+	return func(c *Config) {
+		c.SummaryInterval = d
+	}
+}
+
+type state struct {
+	prefix string
+	def    Config
+	log    logging.Logger
+	now    func() time.Time
+
+	mx      sync.Mutex
+	conf    Config
+	buckets map[bucketKey]*bucket
+	looping bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type bucketKey struct {
+	level    string
+	template string
+}
+
+type bucket struct {
+	tokens     float64
+	last       time.Time
+	suppressed int
+}
+
+// New get a instance of Sampler around log. The summaries are logged
+// since the first suppressed message until ShutdownFunc is called,
+// register it to stop the summaries.
+func New(prefix string, log logging.Logger, opts ...Option) *Sampler {
+	def := Config{
+		Burst:           defaultBurst,
+		Rate:            defaultRate,
+		PassLevel:       defaultPassLevel,
+		SummaryInterval: defaultSummaryInterval,
+	}
+
+	for _, opt := range opts {
+		opt(&def)
+	}
+
+	st := &state{
+		prefix:  prefix,
+		def:     def,
+		log:     log,
+		now:     time.Now,
+		conf:    def,
+		buckets: make(map[bucketKey]*bucket),
+		stop:    make(chan struct{}),
+	}
+
+	s := &Sampler{log: log, state: st}
+
+	s.WatcherConfigFunc = func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:       prefix,
+			MainKey:      mainKey,
+			Keys:         schema(def).Names(),
+			Schema:       schema(def),
+			ApplyChanges: st.apply,
+		}
+	}
+
+	s.ShutdownFunc = func() {
+		st.stopOnce.Do(func() {
+			close(st.stop)
+		})
+
+		st.flush()
+	}
+
+	return s
+}
+
+func schema(def Config) daemon.Schema {
+	return daemon.Schema{
+		{
+			Name:        "burst",
+			Type:        daemon.TypeInt,
+			Default:     strconv.Itoa(def.Burst),
+			Description: "Messages of a template logged before limiting, 0 disables limiting.",
+		},
+		{
+			Name:        "rate",
+			Type:        daemon.TypeFloat,
+			Default:     strconv.FormatFloat(def.Rate, 'f', -1, 64),
+			Description: "Messages of a template per second after the burst.",
+		},
+		{
+			Name:        "pass-level",
+			Type:        daemon.TypeString,
+			Default:     def.PassLevel,
+			Description: "Messages of this level and above are never limited, none limits all.",
+		},
+		{
+			Name:        "summary-interval",
+			Type:        daemon.TypeDuration,
+			Default:     def.SummaryInterval.String(),
+			Description: "Period of suppressed messages summaries.",
+		},
+	}
+}
+
+// Config returns the current limits.
+func (s *Sampler) Config() Config {
+	s.state.mx.Lock()
+	defer s.state.mx.Unlock()
+
+	return s.state.conf
+}
+
+// Set changes the limits until the next apply of config.
+func (s *Sampler) Set(conf Config) {
+	s.state.mx.Lock()
+	s.state.conf = conf
+	s.state.mx.Unlock()
+}
+
+func (st *state) apply(cs daemon.ChangeSet) {
+	base := st.prefix + "/" + mainKey + "/"
+	conf := st.def
+
+	for k, v := range cs.Conf {
+		v = strings.TrimSpace(v)
+
+		switch k {
+		case base + "burst":
+			if n, err := strconv.Atoi(v); err == nil {
+				conf.Burst = n
+			}
+
+		case base + "rate":
+			if r, err := strconv.ParseFloat(v, 64); err == nil {
+				conf.Rate = r
+			}
+
+		case base + "pass-level":
+			conf.PassLevel = strings.ToLower(v)
+
+		case base + "summary-interval":
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				conf.SummaryInterval = d
+			}
+		}
+	}
+
+	st.mx.Lock()
+	st.conf = conf
+	st.mx.Unlock()
+}
+
+func (st *state) loop() {
+	for {
+		st.mx.Lock()
+		interval := st.conf.SummaryInterval
+		st.mx.Unlock()
+
+		if interval <= 0 {
+			interval = defaultSummaryInterval
+		}
+
+		t := time.NewTimer(interval)
+
+		select {
+		case <-st.stop:
+			t.Stop()
+
+			return
+		case <-t.C:
+			st.flush()
+		}
+	}
+}
+
+// allow reports whether the message of template is logged.
+func (st *state) allow(level, template string) bool {
+	st.mx.Lock()
+	defer st.mx.Unlock()
+
+	if st.conf.Burst <= 0 || logging.Severity(level) >= logging.Severity(st.conf.PassLevel) {
+		return true
+	}
+
+	now := st.now()
+	key := bucketKey{level: level, template: template}
+
+	b, ok := st.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(st.conf.Burst), last: now}
+		st.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * st.conf.Rate
+	if b.tokens > float64(st.conf.Burst) {
+		b.tokens = float64(st.conf.Burst)
+	}
+
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+
+		return true
+	}
+
+	b.suppressed++
+
+	if !st.looping {
+		st.looping = true
+
+		go st.loop()
+	}
+
+	return false
+}
+
+// flush logs the summaries of suppressed messages and forgets
+// the templates which have not been limited since the last flush.
+func (st *state) flush() {
+	type summary struct {
+		bucketKey
+		suppressed int
+	}
+
+	st.mx.Lock()
+
+	var summaries []summary
+
+	for key, b := range st.buckets {
+		if b.suppressed == 0 {
+			delete(st.buckets, key)
+
+			continue
+		}
+
+		summaries = append(summaries, summary{bucketKey: key, suppressed: b.suppressed})
+		b.suppressed = 0
+	}
+
+	st.mx.Unlock()
+
+	for _, s := range summaries {
+		st.log.WithFields(map[string]interface{}{
+			"template":   s.template,
+			"level":      s.level,
+			"suppressed": s.suppressed,
+		}).Warnf("suppressed %d messages", s.suppressed)
+	}
+}
+
+func (s *Sampler) with(log logging.Logger) *Sampler {
+	return &Sampler{
+		log:               log,
+		state:             s.state,
+		WatcherConfigFunc: s.WatcherConfigFunc,
+		ShutdownFunc:      s.ShutdownFunc,
+	}
+}
+
+// Enabled implements logging.Enabler.
+func (s *Sampler) Enabled(level string) bool {
+	return logging.Enabled(s.log, level)
+}
+
+func (s *Sampler) Infof(format string, args ...interface{}) {
+	if s.state.allow(logging.LevelInfo, format) {
+		s.log.Infof(format, args...)
+	}
+}
+
+func (s *Sampler) Errorf(format string, args ...interface{}) {
+	if s.state.allow(logging.LevelError, format) {
+		s.log.Errorf(format, args...)
+	}
+}
+
+func (s *Sampler) Debugf(format string, args ...interface{}) {
+	if s.state.allow(logging.LevelDebug, format) {
+		s.log.Debugf(format, args...)
+	}
+}
+
+func (s *Sampler) Warnf(format string, args ...interface{}) {
+	if s.state.allow(logging.LevelWarn, format) {
+		s.log.Warnf(format, args...)
+	}
+}
+
+func (s *Sampler) Infow(msg string, keysAndValues ...interface{}) {
+	if s.state.allow(logging.LevelInfo, msg) {
+		s.log.Infow(msg, keysAndValues...)
+	}
+}
+
+func (s *Sampler) Errorw(msg string, keysAndValues ...interface{}) {
+	if s.state.allow(logging.LevelError, msg) {
+		s.log.Errorw(msg, keysAndValues...)
+	}
+}
+
+func (s *Sampler) Debugw(msg string, keysAndValues ...interface{}) {
+	if s.state.allow(logging.LevelDebug, msg) {
+		s.log.Debugw(msg, keysAndValues...)
+	}
+}
+
+func (s *Sampler) Warnw(msg string, keysAndValues ...interface{}) {
+	if s.state.allow(logging.LevelWarn, msg) {
+		s.log.Warnw(msg, keysAndValues...)
+	}
+}
+
+func (s *Sampler) WithFields(fields map[string]interface{}) logging.Logger {
+	return s.with(s.log.WithFields(fields))
+}
+
+func (s *Sampler) WithError(err error) logging.Logger {
+	return s.with(s.log.WithError(err))
+}
+
+func (s *Sampler) WithContext(ctx context.Context) logging.Logger {
+	return s.with(s.log.WithContext(ctx))
+}
@@ -0,0 +1,110 @@
+package sampling
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/logging/wrapzerolog"
+	"github.com/rs/zerolog"
+)
+
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var res []map[string]interface{}
+
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		line := map[string]interface{}{}
+		if err := dec.Decode(&line); err != nil {
+			t.Fatalf("failed to decode log, %s", err)
+		}
+
+		res = append(res, line)
+	}
+
+	return res
+}
+
+func TestSampler(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	s := New("my-daemon", wrapzerolog.New(zerolog.New(buf)), WithBurst(2), WithRate(1))
+	defer s.ShutdownFunc()
+
+	now := time.Now()
+	s.state.now = func() time.Time { return now }
+
+	s.Infof("failed to ping mysql, %d", 0)
+
+	if s.state.looping {
+		t.Error("summaries must start on the first suppressed message")
+	}
+
+	for i := 1; i < 5; i++ {
+		s.WithFields(map[string]interface{}{"attempt": i}).Infof("failed to ping mysql, %d", i)
+	}
+
+	for i := 0; i < 5; i++ {
+		s.Errorf("failed to ping mysql")
+	}
+
+	if !s.state.looping {
+		t.Error("summaries are not started")
+	}
+
+	now = now.Add(time.Second)
+	s.Infof("failed to ping mysql, %d", 5)
+	s.Infof("failed to ping mysql, %d", 6)
+
+	got := lines(t, buf)
+	if len(got) != 5 {
+		t.Fatalf("expected 5 lines, got %d: %v", len(got), got)
+	}
+
+	s.state.flush()
+
+	got = lines(t, buf)
+	if len(got) != 2 {
+		t.Fatalf("expected summaries, got %v", got)
+	}
+
+	summaries := map[interface{}]interface{}{}
+	for _, line := range got {
+		summaries[line["template"]] = line["message"]
+	}
+
+	if summaries["failed to ping mysql, %d"] != "suppressed 4 messages" ||
+		summaries["failed to ping mysql"] != "suppressed 3 messages" {
+		t.Errorf("unexpected summaries %v", got)
+	}
+}
+
+func TestSampler_apply(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	s := New("my-daemon", wrapzerolog.New(zerolog.New(buf)))
+	defer s.ShutdownFunc()
+
+	apply := s.WatcherConfigFunc().ApplyChanges
+	apply(daemon.NewChangeSet(map[string]string{
+		"my-daemon/log-sampling/burst":      "1",
+		"my-daemon/log-sampling/pass-level": "error",
+	}, nil))
+
+	s.Infof("boom")
+	s.Infof("boom")
+	s.Errorf("boom")
+	s.Errorf("boom")
+
+	if got := lines(t, buf); len(got) != 3 {
+		t.Fatalf("expected 3 lines, got %v", got)
+	}
+
+	apply(daemon.NewChangeSet(nil, nil))
+
+	if conf := s.Config(); conf.Burst != defaultBurst || conf.PassLevel != defaultPassLevel {
+		t.Errorf("expected defaults, got %+v", conf)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	// ChannelKey is the field name of Config.Channel in records.
+	ChannelKey = "channel"
+
+	// BuildIDKey is the field name of Config.BuildID in records.
+	BuildIDKey = "build_id"
+)
+
+// Record is a log entry passed to Sink.
+type Record struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Sink sends records to a log collector, e.g. Graylog or journald.
+type Sink interface {
+	Send(r Record) error
+}
+
+// SyslogSeverity returns the severity of level defined by RFC 5424,
+// it is used by syslog, GELF and journald.
+func SyslogSeverity(level string) int {
+	switch Severity(level) {
+	case 0:
+		return 7
+	case 1:
+		return 6
+	case 2:
+		return 4
+	default:
+		return 3
+	}
+}
+
+// NewSinkLogger returns a Logger which sends records to sink.
+// Records have Channel and BuildID of conf as fields, the level
+// is conf.Level, error if it is empty. Errors of sink are written
+// to os.Stderr.
+func NewSinkLogger(sink Sink, conf Config) Logger {
+	if conf.Level == "" {
+		conf.Level = LevelError
+	}
+
+	fields := map[string]interface{}{}
+
+	if conf.Channel != "" {
+		fields[ChannelKey] = conf.Channel
+	}
+
+	if conf.BuildID != "" {
+		fields[BuildIDKey] = conf.BuildID
+	}
+
+	return &sinkLog{
+		sink:   sink,
+		level:  Severity(conf.Level),
+		fields: fields,
+		errOut: os.Stderr,
+	}
+}
+
+type sinkLog struct {
+	sink   Sink
+	level  int
+	fields map[string]interface{}
+	errOut io.Writer
+}
+
+func (l *sinkLog) send(level, msg string, extra map[string]interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(l.fields)+len(extra))
+
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+
+	for k, v := range extra {
+		fields[k] = v
+	}
+
+	r := Record{Time: time.Now(), Level: level, Message: msg, Fields: fields}
+
+	if err := l.sink.Send(r); err != nil {
+		fmt.Fprintf(l.errOut, "failed to send log record, %s\n", err)
+	}
+}
+
+func (l *sinkLog) with(fields map[string]interface{}) *sinkLog {
+	merged := make(map[string]interface{}, len(l.fields)+len(fields))
+
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return &sinkLog{sink: l.sink, level: l.level, fields: merged, errOut: l.errOut}
+}
+
+// Enabled implements Enabler.
+func (l *sinkLog) Enabled(level string) bool {
+	return Severity(level) >= l.level
+}
+
+func (l *sinkLog) Infof(format string, args ...interface{}) {
+	if l.Enabled(LevelInfo) {
+		l.send(LevelInfo, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (l *sinkLog) Errorf(format string, args ...interface{}) {
+	if l.Enabled(LevelError) {
+		l.send(LevelError, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (l *sinkLog) Debugf(format string, args ...interface{}) {
+	if l.Enabled(LevelDebug) {
+		l.send(LevelDebug, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (l *sinkLog) Warnf(format string, args ...interface{}) {
+	if l.Enabled(LevelWarn) {
+		l.send(LevelWarn, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (l *sinkLog) Infow(msg string, keysAndValues ...interface{}) {
+	l.send(LevelInfo, msg, Fields(keysAndValues...))
+}
+
+func (l *sinkLog) Errorw(msg string, keysAndValues ...interface{}) {
+	l.send(LevelError, msg, Fields(keysAndValues...))
+}
+
+func (l *sinkLog) Debugw(msg string, keysAndValues ...interface{}) {
+	l.send(LevelDebug, msg, Fields(keysAndValues...))
+}
+
+func (l *sinkLog) Warnw(msg string, keysAndValues ...interface{}) {
+	l.send(LevelWarn, msg, Fields(keysAndValues...))
+}
+
+func (l *sinkLog) WithFields(fields map[string]interface{}) Logger {
+	return l.with(fields)
+}
+
+func (l *sinkLog) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+
+	return l.with(map[string]interface{}{ErrorKey: err.Error()})
+}
+
+func (l *sinkLog) WithContext(ctx context.Context) Logger {
+	return l.with(FieldsFromContext(ctx))
+}
@@ -0,0 +1,118 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogHandler exposes the logger as slog.Handler, so libraries logging
+// through log/slog write to it. Records below level are dropped, if level
+// is nil all records are passed and the logger filters them itself.
+// Attributes of groups are named as "group.key".
+func NewSlogHandler(l Logger, level slog.Leveler) slog.Handler {
+	return &slogHandler{log: l, level: level}
+}
+
+type slogHandler struct {
+	log    Logger
+	level  slog.Leveler
+	prefix string
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.level == nil {
+		return true
+	}
+
+	return level >= h.level.Level()
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	kv := make([]interface{}, 0, r.NumAttrs()*2)
+
+	r.Attrs(func(a slog.Attr) bool {
+		kv = appendAttr(kv, h.prefix, a)
+
+		return true
+	})
+
+	log := h.log.WithContext(ctx)
+
+	switch {
+	case r.Level >= slog.LevelError:
+		log.Errorw(r.Message, kv...)
+	case r.Level >= slog.LevelWarn:
+		log.Warnw(r.Message, kv...)
+	case r.Level >= slog.LevelInfo:
+		log.Infow(r.Message, kv...)
+	default:
+		log.Debugw(r.Message, kv...)
+	}
+
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	kv := make([]interface{}, 0, len(attrs)*2)
+	for _, a := range attrs {
+		kv = appendAttr(kv, h.prefix, a)
+	}
+
+	return &slogHandler{
+		log:    h.log.WithFields(Fields(kv...)),
+		level:  h.level,
+		prefix: h.prefix,
+	}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &slogHandler{
+		log:    h.log,
+		level:  h.level,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+func appendAttr(kv []interface{}, prefix string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+
+	if a.Equal(slog.Attr{}) {
+		return kv
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+
+		for _, ga := range a.Value.Group() {
+			kv = appendAttr(kv, prefix, ga)
+		}
+
+		return kv
+	}
+
+	return append(kv, prefix+a.Key, a.Value.Any())
+}
@@ -0,0 +1,237 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package syslog sends logs by RFC 5424 syslog protocol.
+package syslog
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/imega/daemon/logging"
+	"github.com/imega/daemon/logging/internal/netconn"
+)
+
+const (
+	// FacilityDaemon is the facility of system daemons.
+	FacilityDaemon = 3
+
+	// DefaultSDID is the SD-ID of fields, 32473 is the enterprise
+	// number reserved for documentation by RFC 5612.
+	DefaultSDID = "fields@32473"
+
+	timeFormat = "2006-01-02T15:04:05.000000Z07:00"
+	nilValue   = "-"
+
+	maxHostname  = 255
+	maxAppName   = 48
+	maxProcID    = 128
+	maxParamName = 32
+)
+
+// Sink is a logging.Sink which sends RFC 5424 messages, the fields
+// are the parameters of a structured data element. APP-NAME is
+// the channel field or the name set by WithAppName.
+type Sink struct {
+	conn     *netconn.Conn
+	facility int
+	hostname string
+	appName  string
+	procID   string
+	sdID     string
+}
+
+// Option .
+type Option func(*Sink)
+
+// WithFacility sets the facility, FacilityDaemon by default.
+func WithFacility(facility int) Option {
+	return func(s *Sink) {
+		s.facility = facility
+	}
+}
+
+// WithHostname sets HOSTNAME, the hostname by default.
+func WithHostname(hostname string) Option {
+	return func(s *Sink) {
+		s.hostname = hostname
+	}
+}
+
+// WithAppName sets APP-NAME of records without the channel field.
+func WithAppName(name string) Option {
+	return func(s *Sink) {
+		s.appName = name
+	}
+}
+
+// WithSDID sets SD-ID of the fields element, DefaultSDID by default.
+func WithSDID(id string) Option {
+	return func(s *Sink) {
+		s.sdID = id
+	}
+}
+
+// New get a instance of Sink, network is udp, tcp, unix or unixgram.
+// Messages over streams are framed by octet counting (RFC 6587).
+func New(network, addr string, opts ...Option) (*Sink, error) {
+	hostname, _ := os.Hostname()
+
+	s := &Sink{
+		facility: FacilityDaemon,
+		hostname: hostname,
+		procID:   strconv.Itoa(os.Getpid()),
+		sdID:     DefaultSDID,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	conn, err := netconn.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s.conn = conn
+
+	return s, nil
+}
+
+// NewLogger returns logging.Logger which sends to syslog.
+func NewLogger(conf logging.Config, network, addr string, opts ...Option) (logging.Logger, error) {
+	s, err := New(network, addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return logging.NewSinkLogger(s, conf), nil
+}
+
+// Send implements logging.Sink.
+func (s *Sink) Send(r logging.Record) error {
+	msg := s.message(r)
+
+	if s.conn.Stream() {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+
+	return s.conn.Write([]byte(msg))
+}
+
+// Close closes the connection.
+func (s *Sink) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Sink) message(r logging.Record) string {
+	appName := s.appName
+	if ch, ok := r.Fields[logging.ChannelKey].(string); ok && ch != "" {
+		appName = ch
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(
+		&sb,
+		"<%d>1 %s %s %s %s %s ",
+		s.facility*8+logging.SyslogSeverity(r.Level),
+		r.Time.Format(timeFormat),
+		header(s.hostname, maxHostname),
+		header(appName, maxAppName),
+		header(s.procID, maxProcID),
+		nilValue,
+	)
+
+	s.writeSD(&sb, r.Fields)
+
+	if r.Message != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(r.Message)
+	}
+
+	return sb.String()
+}
+
+func (s *Sink) writeSD(sb *strings.Builder, fields map[string]interface{}) {
+	if len(fields) == 0 {
+		sb.WriteString(nilValue)
+
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	sb.WriteByte('[')
+	sb.WriteString(s.sdID)
+
+	for _, k := range keys {
+		fmt.Fprintf(sb, ` %s="%s"`, paramName(k), escape(fmt.Sprint(fields[k])))
+	}
+
+	sb.WriteByte(']')
+}
+
+// header returns printable ASCII without spaces, the nil value if v is empty.
+func header(v string, max int) string {
+	v = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+
+		return r
+	}, v)
+
+	if v == "" {
+		return nilValue
+	}
+
+	if len(v) > max {
+		v = v[:max]
+	}
+
+	return v
+}
+
+func paramName(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+
+		return r
+	}, v)
+
+	if v == "" {
+		return "_"
+	}
+
+	if len(v) > maxParamName {
+		v = v[:maxParamName]
+	}
+
+	return v
+}
+
+func escape(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(v)
+}
@@ -0,0 +1,126 @@
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imega/daemon/logging"
+)
+
+func TestSink_message(t *testing.T) {
+	s := &Sink{facility: FacilityDaemon, hostname: "test-host", procID: "42", sdID: DefaultSDID}
+
+	msg := s.message(logging.Record{
+		Time:    time.Date(2022, 10, 19, 10, 0, 0, 500000000, time.UTC),
+		Level:   logging.LevelError,
+		Message: "failed to ping mysql",
+		Fields: map[string]interface{}{
+			"channel":  "my-daemon",
+			"build_id": "1",
+			"query":    `select "a]"`,
+		},
+	})
+
+	expected := `<27>1 2022-10-19T10:00:00.500000Z test-host my-daemon 42 - ` +
+		`[fields@32473 build_id="1" channel="my-daemon" query="select \"a\]\""] failed to ping mysql`
+
+	if msg != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, msg)
+	}
+}
+
+func TestSink_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	msgs := make(chan string, 2)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		for {
+			size, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+
+			n, _ := strconv.Atoi(strings.TrimSpace(size))
+			msg := make([]byte, n)
+
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+
+			msgs <- string(msg)
+		}
+	}()
+
+	log, err := NewLogger(logging.Config{Channel: "my-daemon"}, "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log.Infof("skipped")
+	log.Errorf("first")
+	log.WithError(io.EOF).Errorf("second")
+
+	for _, suffix := range []string{
+		`[fields@32473 channel="my-daemon"] first`,
+		`[fields@32473 channel="my-daemon" error="EOF"] second`,
+	} {
+		select {
+		case msg := <-msgs:
+			if !strings.HasPrefix(msg, "<27>1 ") || !strings.HasSuffix(msg, suffix) {
+				t.Errorf("unexpected message %s", msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+}
+
+func TestSink_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	s, err := New("udp", pc.LocalAddr().String(), WithAppName("app"), WithFacility(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Send(logging.Record{Time: time.Now(), Level: logging.LevelDebug, Message: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	buf := make([]byte, 1024)
+
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<15>1 ") || !strings.Contains(msg, " app ") || !strings.HasSuffix(msg, " - - test") {
+		t.Errorf("unexpected message %s", msg)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wraplogrus
+
+import (
+	"context"
+
+	"github.com/imega/daemon/logging"
+	"github.com/sirupsen/logrus"
+)
+
+// Logger implements logging.Logger on top of logrus.
+type Logger struct {
+	entry *logrus.Entry
+}
+
+// NewLogger create a new logging.Logger, see New.
+func NewLogger(conf Config) *Logger {
+	return Wrap(New(conf))
+}
+
+// Wrap get a instance of logging.Logger with the entry.
+func Wrap(entry *logrus.Entry) *Logger {
+	return &Logger{entry: entry}
+}
+
+// Entry returns the wrapped entry.
+func (l *Logger) Entry() *logrus.Entry {
+	return l.entry
+}
+
+// Enabled implements logging.Enabler.
+func (l *Logger) Enabled(level string) bool {
+	return l.entry.Logger.IsLevelEnabled(parseLevel(level))
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.entry.Infof(format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.entry.Errorf(format, args...)
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.entry.Debugf(format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.entry.Warnf(format, args...)
+}
+
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.logw(logrus.InfoLevel, msg, keysAndValues)
+}
+
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.logw(logrus.ErrorLevel, msg, keysAndValues)
+}
+
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.logw(logrus.DebugLevel, msg, keysAndValues)
+}
+
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.logw(logrus.WarnLevel, msg, keysAndValues)
+}
+
+func (l *Logger) WithFields(fields map[string]interface{}) logging.Logger {
+	return &Logger{entry: l.entry.WithFields(fields)}
+}
+
+func (l *Logger) WithError(err error) logging.Logger {
+	return &Logger{entry: l.entry.WithField(logging.ErrorKey, err)}
+}
+
+func (l *Logger) WithContext(ctx context.Context) logging.Logger {
+	return &Logger{
+		entry: l.entry.WithContext(ctx).WithFields(logging.FieldsFromContext(ctx)),
+	}
+}
+
+func (l *Logger) logw(level logrus.Level, msg string, keysAndValues []interface{}) {
+	if !l.entry.Logger.IsLevelEnabled(level) {
+		return
+	}
+
+	l.entry.WithFields(logging.Fields(keysAndValues...)).Log(level, msg)
+}
@@ -1,6 +1,11 @@
 package wraplogrus
 
-import "github.com/sirupsen/logrus"
+import (
+	"io"
+
+	"github.com/imega/daemon/logging/levels"
+	"github.com/sirupsen/logrus"
+)
 
 // Config is a configuration logger.
 type Config struct {
@@ -9,6 +14,12 @@ type Config struct {
 	Level         string
 	TextFormatter *logrus.TextFormatter
 	JSONFormatter *logrus.JSONFormatter
+
+	// Levels changes the level at runtime, Level is ignored if it is set.
+	Levels *levels.Levels
+
+	// Output of logs, e.g. filesink.File, os.Stderr by default.
+	Output io.Writer
 }
 
 // New create a new logger.
@@ -17,12 +28,21 @@ func New(conf Config) *logrus.Entry {
 		conf.Level = "error"
 	}
 
-	logLevel, err := logrus.ParseLevel(conf.Level)
-	if err != nil {
-		logLevel = logrus.ErrorLevel
+	logrus.SetLevel(parseLevel(conf.Level))
+
+	if conf.Levels != nil {
+		lv := conf.Levels
+		setLevel := func() {
+			logrus.SetLevel(parseLevel(lv.Level("")))
+		}
+
+		setLevel()
+		lv.Subscribe(setLevel)
 	}
 
-	logrus.SetLevel(logLevel)
+	if conf.Output != nil {
+		logrus.SetOutput(conf.Output)
+	}
 
 	if conf.TextFormatter != nil {
 		logrus.SetFormatter(conf.TextFormatter)
@@ -39,3 +59,32 @@ func New(conf Config) *logrus.Entry {
 		},
 	)
 }
+
+// Component returns a logger of component with its own level,
+// the level follows the override of component in lv until
+// the returned function is called.
+func Component(log *logrus.Entry, lv *levels.Levels, name string) (*logrus.Entry, func()) {
+	logger := &logrus.Logger{
+		Out:          log.Logger.Out,
+		Hooks:        log.Logger.Hooks,
+		Formatter:    log.Logger.Formatter,
+		ReportCaller: log.Logger.ReportCaller,
+		ExitFunc:     log.Logger.ExitFunc,
+		Level:        parseLevel(lv.Level(name)),
+	}
+
+	unsubscribe := lv.Subscribe(func() {
+		logger.SetLevel(parseLevel(lv.Level(name)))
+	})
+
+	return logger.WithFields(log.Data).WithField("component", name), unsubscribe
+}
+
+func parseLevel(level string) logrus.Level {
+	logLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return logrus.ErrorLevel
+	}
+
+	return logLevel
+}
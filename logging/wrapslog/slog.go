@@ -0,0 +1,216 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrapslog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/imega/daemon/logging"
+	"github.com/imega/daemon/logging/levels"
+)
+
+// Logger implements logging.Logger on top of slog.
+type Logger struct {
+	wrapped *slog.Logger
+	ctx     context.Context
+}
+
+type options struct {
+	out     io.Writer
+	text    bool
+	handler func(slog.Leveler) slog.Handler
+	levels  *levels.Levels
+}
+
+// Option .
+type Option func(*options)
+
+// WithWriter changes the output, os.Stderr by default.
+func WithWriter(w io.Writer) Option {
+	return func(o *options) {
+		o.out = w
+	}
+}
+
+// WithText writes records by slog.TextHandler instead of slog.JSONHandler.
+func WithText() Option {
+	return func(o *options) {
+		o.text = true
+	}
+}
+
+// WithHandler writes records by the handler, it must filter records
+// by the level passed.
+func WithHandler(fn func(level slog.Leveler) slog.Handler) Option {
+	return func(o *options) {
+		o.handler = fn
+	}
+}
+
+// WithLevels changes the level at runtime, Config.Level is ignored.
+func WithLevels(lv *levels.Levels) Option {
+	return func(o *options) {
+		o.levels = lv
+	}
+}
+
+// New create a new logger, the records have the channel and build_id
+// attributes. The level is error if Config.Level is empty.
+func New(conf logging.Config, opts ...Option) *Logger {
+	o := &options{out: os.Stderr}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if conf.Level == "" {
+		conf.Level = "error"
+	}
+
+	level := &slog.LevelVar{}
+	level.Set(ParseLevel(conf.Level))
+
+	if o.levels != nil {
+		lv := o.levels
+		setLevel := func() {
+			level.Set(ParseLevel(lv.Level("")))
+		}
+
+		setLevel()
+		lv.Subscribe(setLevel)
+	}
+
+	var h slog.Handler
+
+	switch {
+	case o.handler != nil:
+		h = o.handler(level)
+	case o.text:
+		h = slog.NewTextHandler(o.out, &slog.HandlerOptions{Level: level})
+	default:
+		h = slog.NewJSONHandler(o.out, &slog.HandlerOptions{Level: level})
+	}
+
+	return Wrap(slog.New(h).With(
+		"channel", conf.Channel,
+		"build_id", conf.BuildID,
+	))
+}
+
+// Wrap get a instance of logging.Logger with the slog logger.
+func Wrap(l *slog.Logger) *Logger {
+	return &Logger{wrapped: l, ctx: context.Background()}
+}
+
+// Slog returns the wrapped logger.
+func (l *Logger) Slog() *slog.Logger {
+	return l.wrapped
+}
+
+// ParseLevel converts the name of level to slog.Level,
+// unknown names are error.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "trace", "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn", "warning":
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// Enabled implements logging.Enabler.
+func (l *Logger) Enabled(level string) bool {
+	return l.wrapped.Enabled(l.ctx, ParseLevel(level))
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(slog.LevelInfo, format, args)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(slog.LevelError, format, args)
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(slog.LevelDebug, format, args)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logf(slog.LevelWarn, format, args)
+}
+
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.wrapped.Log(l.ctx, slog.LevelInfo, msg, keysAndValues...)
+}
+
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.wrapped.Log(l.ctx, slog.LevelError, msg, keysAndValues...)
+}
+
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.wrapped.Log(l.ctx, slog.LevelDebug, msg, keysAndValues...)
+}
+
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.wrapped.Log(l.ctx, slog.LevelWarn, msg, keysAndValues...)
+}
+
+func (l *Logger) WithFields(fields map[string]interface{}) logging.Logger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	args := make([]interface{}, 0, len(fields)*2)
+	for _, k := range keys {
+		args = append(args, k, fields[k])
+	}
+
+	return &Logger{wrapped: l.wrapped.With(args...), ctx: l.ctx}
+}
+
+func (l *Logger) WithError(err error) logging.Logger {
+	return &Logger{wrapped: l.wrapped.With(logging.ErrorKey, err), ctx: l.ctx}
+}
+
+// WithContext passes ctx to the handler and adds the fields stored in ctx.
+func (l *Logger) WithContext(ctx context.Context) logging.Logger {
+	log := &Logger{wrapped: l.wrapped, ctx: ctx}
+
+	return log.WithFields(logging.FieldsFromContext(ctx))
+}
+
+func (l *Logger) logf(level slog.Level, format string, args []interface{}) {
+	if !l.wrapped.Enabled(l.ctx, level) {
+		return
+	}
+
+	l.wrapped.Log(l.ctx, level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,96 @@
+package wrapslog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/imega/daemon/logging"
+	"github.com/imega/daemon/logging/levels"
+	"github.com/imega/daemon/logging/wrapslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func removeTime(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+
+	return a
+}
+
+func TestNew(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	lv := levels.New("my-daemon", "info")
+	logger := wrapslog.New(
+		logging.Config{Channel: "my-daemon", BuildID: "1"},
+		wrapslog.WithLevels(lv),
+		wrapslog.WithHandler(func(level slog.Leveler) slog.Handler {
+			return slog.NewJSONHandler(buf, &slog.HandlerOptions{
+				Level:       level,
+				ReplaceAttr: removeTime,
+			})
+		}),
+	)
+
+	logger.Debugf("skipped")
+	logger.WithError(errors.New("boom")).Warnw("test", "attempt", 2)
+
+	expected := map[string]interface{}{
+		"level":    "WARN",
+		"channel":  "my-daemon",
+		"build_id": "1",
+		"error":    "boom",
+		"attempt":  float64(2),
+		"msg":      "test",
+	}
+
+	actual := map[string]interface{}{}
+
+	err := json.NewDecoder(buf).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, actual)
+	assert.False(t, json.NewDecoder(buf).More())
+
+	lv.Set("debug")
+	logger.Debugf("test %d", 1)
+
+	actual = map[string]interface{}{}
+
+	err = json.NewDecoder(buf).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, "test 1", actual["msg"])
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	logger := wrapslog.Wrap(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		ReplaceAttr: removeTime,
+	})))
+
+	log := slog.New(logging.NewSlogHandler(logger, slog.LevelInfo))
+
+	log.Debug("skipped")
+	log.WithGroup("http").With("method", "GET").Info("test", slog.Int("status", 200))
+
+	expected := map[string]interface{}{
+		"level":       "INFO",
+		"http.method": "GET",
+		"http.status": float64(200),
+		"msg":         "test",
+	}
+
+	actual := map[string]interface{}{}
+
+	err := json.NewDecoder(buf).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, actual)
+	assert.False(t, json.NewDecoder(buf).More())
+}
@@ -1,30 +1,142 @@
 package wrapzerolog
 
 import (
+	"context"
+
 	"github.com/imega/daemon/logging"
+	"github.com/imega/daemon/logging/levels"
 	"github.com/rs/zerolog"
 )
 
 type ZLog struct {
-	wrapped zerolog.Logger
+	wrapped   zerolog.Logger
+	levels    *levels.Levels
+	component string
+}
+
+// Option .
+type Option func(*ZLog)
+
+// WithLevels changes the level at runtime. The wrapped logger
+// must not filter the levels which may be enabled.
+func WithLevels(lv *levels.Levels) Option {
+	return func(l *ZLog) {
+		l.levels = lv
+	}
+}
+
+func New(log zerolog.Logger, opts ...Option) *ZLog {
+	l := &ZLog{wrapped: log}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Component returns a logger of component, its level follows
+// the override of component in Levels.
+func (l *ZLog) Component(name string) *ZLog {
+	return &ZLog{
+		wrapped:   l.wrapped.With().Str("component", name).Logger(),
+		levels:    l.levels,
+		component: name,
+	}
+}
+
+func (l *ZLog) enabled(lvl zerolog.Level) bool {
+	if l.levels == nil {
+		return true
+	}
+
+	level := l.levels.Level(l.component)
+	if level == "" {
+		return true
+	}
+
+	min, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return true
+	}
+
+	return lvl >= min
 }
 
-func New(log zerolog.Logger) *ZLog {
-	return &ZLog{wrapped: log}
+// Enabled implements logging.Enabler.
+func (l *ZLog) Enabled(level string) bool {
+	lvl, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return true
+	}
+
+	return l.enabled(lvl) && lvl >= l.wrapped.GetLevel() && lvl >= zerolog.GlobalLevel()
 }
 
 func (l *ZLog) Infof(format string, args ...interface{}) {
-	l.wrapped.Info().Msgf(format, args...)
+	if l.enabled(zerolog.InfoLevel) {
+		l.wrapped.Info().Msgf(format, args...)
+	}
 }
 
 func (l *ZLog) Errorf(format string, args ...interface{}) {
-	l.wrapped.Error().Msgf(format, args...)
+	if l.enabled(zerolog.ErrorLevel) {
+		l.wrapped.Error().Msgf(format, args...)
+	}
 }
 
 func (l *ZLog) Debugf(format string, args ...interface{}) {
-	l.wrapped.Debug().Msgf(format, args...)
+	if l.enabled(zerolog.DebugLevel) {
+		l.wrapped.Debug().Msgf(format, args...)
+	}
+}
+
+func (l *ZLog) Warnf(format string, args ...interface{}) {
+	if l.enabled(zerolog.WarnLevel) {
+		l.wrapped.Warn().Msgf(format, args...)
+	}
+}
+
+func (l *ZLog) Infow(msg string, keysAndValues ...interface{}) {
+	if l.enabled(zerolog.InfoLevel) {
+		l.wrapped.Info().Fields(logging.Fields(keysAndValues...)).Msg(msg)
+	}
+}
+
+func (l *ZLog) Errorw(msg string, keysAndValues ...interface{}) {
+	if l.enabled(zerolog.ErrorLevel) {
+		l.wrapped.Error().Fields(logging.Fields(keysAndValues...)).Msg(msg)
+	}
+}
+
+func (l *ZLog) Debugw(msg string, keysAndValues ...interface{}) {
+	if l.enabled(zerolog.DebugLevel) {
+		l.wrapped.Debug().Fields(logging.Fields(keysAndValues...)).Msg(msg)
+	}
+}
+
+func (l *ZLog) Warnw(msg string, keysAndValues ...interface{}) {
+	if l.enabled(zerolog.WarnLevel) {
+		l.wrapped.Warn().Fields(logging.Fields(keysAndValues...)).Msg(msg)
+	}
 }
 
 func (l *ZLog) WithFields(fields map[string]interface{}) logging.Logger {
-	return &ZLog{wrapped: l.wrapped.With().Fields(fields).Logger()}
+	return &ZLog{
+		wrapped:   l.wrapped.With().Fields(fields).Logger(),
+		levels:    l.levels,
+		component: l.component,
+	}
+}
+
+func (l *ZLog) WithError(err error) logging.Logger {
+	return &ZLog{
+		wrapped:   l.wrapped.With().AnErr(logging.ErrorKey, err).Logger(),
+		levels:    l.levels,
+		component: l.component,
+	}
+}
+
+func (l *ZLog) WithContext(ctx context.Context) logging.Logger {
+	return l.WithFields(logging.FieldsFromContext(ctx))
 }
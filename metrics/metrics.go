@@ -0,0 +1,290 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics exports the metrics of daemon in Prometheus format.
+//
+// # Example
+//
+//	m := metrics.New(metrics.WithBuildInfo(logConf))
+//	cr := consul.Watch(log, m.Track(h.WatcherConfigFunc, g.WatcherConfigFunc)...).
+//		With(consul.WithRejectFunc(m.Reject))
+//	e, err := health.NewEvaluator(checks, health.WithObserver(m.ObserveHealth))
+//	d.RegisterShutdownFunc(m.TrackShutdown(srv.ShutdownFunc, e.ShutdownFunc)...)
+//	mux.Handle("/metrics", m.Handler())
+package metrics
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/health"
+	"github.com/imega/daemon/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const defaultNamespace = "daemon"
+
+var statuses = []health.Status{health.StatusUp, health.StatusDegraded, health.StatusDown}
+
+// Metrics owns a Prometheus registry with the process and Go runtime
+// collectors and the series of daemon.
+type Metrics struct {
+	registry  *prometheus.Registry
+	namespace string
+	buildInfo *logging.Config
+
+	configApplies    *prometheus.CounterVec
+	configRejections *prometheus.CounterVec
+	healthStatus     *prometheus.GaugeVec
+	healthDuration   *prometheus.HistogramVec
+	shutdownDuration prometheus.Gauge
+
+	mx      sync.Mutex
+	members []daemon.WatcherConfig
+}
+
+// Option .
+type Option func(*Metrics)
+
+// WithNamespace sets the namespace of series of daemon, daemon by default.
+func WithNamespace(ns string) Option {
+	return func(m *Metrics) {
+		m.namespace = ns
+	}
+}
+
+// WithRegistry sets the registry, the collectors are registered to it.
+func WithRegistry(r *prometheus.Registry) Option {
+	return func(m *Metrics) {
+		m.registry = r
+	}
+}
+
+// WithBuildInfo exports build_info with channel and build_id of conf.
+func WithBuildInfo(conf logging.Config) Option {
+	return func(m *Metrics) {
+		m.buildInfo = &conf
+	}
+}
+
+// New get a instance of Metrics.
+func New(opts ...Option) *Metrics {
+	m := &Metrics{
+		registry:  prometheus.NewRegistry(),
+		namespace: defaultNamespace,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.configApplies = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Name:      "config_applies_total",
+		Help:      "Number of configs applied to WatcherConfig.",
+	}, []string{"prefix", "main_key"})
+
+	m.configRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Name:      "config_rejections_total",
+		Help:      "Number of configs of WatcherConfig which are not applied.",
+	}, []string{"prefix", "main_key"})
+
+	m.healthStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: m.namespace,
+		Name:      "health_check_status",
+		Help:      "Status of health check, 1 for the current status and 0 for others.",
+	}, []string{"check", "status"})
+
+	m.healthDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: m.namespace,
+		Name:      "health_check_duration_seconds",
+		Help:      "Duration of health check.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"check"})
+
+	m.shutdownDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: m.namespace,
+		Name:      "shutdown_duration_seconds",
+		Help:      "Duration of shutdown, it grows until all ShutdownFuncs have finished.",
+	})
+
+	m.registry.MustRegister(
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewGoCollector(),
+		m.configApplies,
+		m.configRejections,
+		m.healthStatus,
+		m.healthDuration,
+		m.shutdownDuration,
+	)
+
+	if m.buildInfo != nil {
+		info := prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: m.namespace,
+			Name:      "build_info",
+			Help:      "Build of daemon, the value is always 1.",
+			ConstLabels: prometheus.Labels{
+				"channel":    m.buildInfo.Channel,
+				"build_id":   m.buildInfo.BuildID,
+				"go_version": runtime.Version(),
+			},
+		})
+		info.Set(1)
+
+		m.registry.MustRegister(info)
+	}
+
+	return m
+}
+
+// Registry returns the registry, register the metrics of application to it.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler returns an http.Handler which exports the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Track returns the WatcherConfigFuncs which count applied configs.
+// The merged config of Group is counted for every member of group
+// which has keys in it, track all members.
+func (m *Metrics) Track(f ...daemon.WatcherConfigFunc) []daemon.WatcherConfigFunc {
+	res := make([]daemon.WatcherConfigFunc, 0, len(f))
+
+	for _, fn := range f {
+		wConf := fn()
+		apply := wConf.ChangesFunc()
+		labels := labelsOf(wConf)
+
+		m.configApplies.With(labels)
+		m.configRejections.With(labels)
+
+		m.mx.Lock()
+		m.members = append(m.members, wConf)
+		m.mx.Unlock()
+
+		wConf.ApplyChanges = func(cs daemon.ChangeSet) {
+			for _, member := range m.group(wConf, cs.Conf) {
+				m.configApplies.With(labelsOf(member)).Inc()
+			}
+
+			apply(cs)
+		}
+
+		res = append(res, func() daemon.WatcherConfig { return wConf })
+	}
+
+	return res
+}
+
+// Reject counts the config which is not applied, use it
+// as the RejectFunc of watcher.
+func (m *Metrics) Reject(wConf daemon.WatcherConfig, conf map[string]string, _ error) {
+	for _, member := range m.group(wConf, conf) {
+		m.configRejections.With(labelsOf(member)).Inc()
+	}
+}
+
+func labelsOf(wConf daemon.WatcherConfig) prometheus.Labels {
+	return prometheus.Labels{"prefix": wConf.Prefix, "main_key": wConf.MainKey}
+}
+
+// group returns wConf and the members of its group with keys in conf.
+func (m *Metrics) group(wConf daemon.WatcherConfig, conf map[string]string) []daemon.WatcherConfig {
+	res := []daemon.WatcherConfig{wConf}
+
+	if wConf.Group == "" {
+		return res
+	}
+
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	for _, member := range m.members {
+		if member.Group != wConf.Group ||
+			(member.Prefix == wConf.Prefix && member.MainKey == wConf.MainKey) {
+			continue
+		}
+
+		base := member.Prefix + "/" + member.MainKey + "/"
+
+		for k := range conf {
+			if strings.HasPrefix(k, base) {
+				res = append(res, member)
+
+				break
+			}
+		}
+	}
+
+	return res
+}
+
+// ObserveHealth exports the results of report, use it
+// as the observer of health.Evaluator.
+func (m *Metrics) ObserveHealth(report health.Report) {
+	for _, r := range report.Checks {
+		for _, st := range statuses {
+			v := 0.0
+			if r.Status == st {
+				v = 1
+			}
+
+			m.healthStatus.WithLabelValues(r.Name, string(st)).Set(v)
+		}
+
+		m.healthDuration.WithLabelValues(r.Name).Observe(r.Duration.Seconds())
+	}
+}
+
+// TrackShutdown returns the ShutdownFuncs which measure the duration
+// of shutdown, from the start of the first one to the end of the last one.
+func (m *Metrics) TrackShutdown(f ...daemon.ShutdownFunc) []daemon.ShutdownFunc {
+	var (
+		once  sync.Once
+		start time.Time
+		mx    sync.Mutex
+	)
+
+	res := make([]daemon.ShutdownFunc, 0, len(f))
+
+	for _, fn := range f {
+		fn := fn
+
+		res = append(res, func() {
+			once.Do(func() {
+				mx.Lock()
+				start = time.Now()
+				mx.Unlock()
+			})
+
+			fn()
+
+			mx.Lock()
+			m.shutdownDuration.Set(time.Since(start).Seconds())
+			mx.Unlock()
+		})
+	}
+
+	return res
+}
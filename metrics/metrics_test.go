@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imega/daemon"
+	"github.com/imega/daemon/health"
+	"github.com/imega/daemon/logging"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New(WithBuildInfo(logging.Config{Channel: "app", BuildID: "abc123"}))
+
+	applied := 0
+	wcf := m.Track(func() daemon.WatcherConfig {
+		return daemon.WatcherConfig{
+			Prefix:  "app",
+			MainKey: "http",
+			Keys:    []string{"timeout"},
+			Schema:  daemon.Schema{{Name: "timeout", Type: daemon.TypeDuration}},
+			ApplyFunc: func(conf, reset map[string]string) {
+				applied++
+			},
+		}
+	})
+
+	apply := wcf[0]().ChangesFunc()
+	apply(daemon.NewChangeSet(map[string]string{"app/http/timeout": "1s"}, nil))
+	apply(daemon.NewChangeSet(map[string]string{"app/http/timeout": "one"}, nil))
+	apply(daemon.NewChangeSet(map[string]string{"app/http/unknown": "1"}, nil))
+
+	if applied != 3 {
+		t.Errorf("expected 3 applies, got %d", applied)
+	}
+
+	group := m.Track(
+		func() daemon.WatcherConfig {
+			return daemon.WatcherConfig{
+				Prefix: "instance", MainKey: "mysql", Keys: []string{"host"}, Group: "mysql",
+				ApplyFunc: func(conf, reset map[string]string) {},
+			}
+		},
+		func() daemon.WatcherConfig {
+			return daemon.WatcherConfig{
+				Prefix: "app", MainKey: "mysql", Keys: []string{"password"}, Group: "mysql",
+				ApplyFunc: func(conf, reset map[string]string) {},
+			}
+		},
+	)
+
+	group[0]().ChangesFunc()(daemon.NewChangeSet(map[string]string{
+		"instance/mysql/host": "db:3306",
+		"app/mysql/password":  "secret",
+	}, nil))
+
+	m.Reject(group[0](), map[string]string{
+		"instance/mysql/host": "db:3306",
+		"app/mysql/password":  "vault://secret/data/db#password",
+	}, errors.New("vault is unavailable"))
+	m.Reject(wcf[0](), map[string]string{"app/http/timeout": "1s"}, errors.New("failed to decrypt"))
+
+	e, err := health.NewEvaluator([]health.Check{
+		health.FromFunc("mysql", func() bool { return true }),
+		{Name: "redis", Optional: true, Func: func(context.Context) error { return errors.New("timeout") }},
+	}, health.WithObserver(m.ObserveHealth))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Evaluate()
+
+	sf := m.TrackShutdown(func() { time.Sleep(10 * time.Millisecond) })
+	sf[0]()
+
+	ht := httptest.NewRecorder()
+	m.Handler().ServeHTTP(ht, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ht.Code != http.StatusOK {
+		t.Fatalf("Handler() = %d, want %d", ht.Code, http.StatusOK)
+	}
+
+	b, _ := io.ReadAll(ht.Body)
+	body := string(b)
+
+	for _, want := range []string{
+		`daemon_config_applies_total{main_key="http",prefix="app"} 3`,
+		`daemon_config_rejections_total{main_key="http",prefix="app"} 1`,
+		`daemon_config_applies_total{main_key="mysql",prefix="instance"} 1`,
+		`daemon_config_applies_total{main_key="mysql",prefix="app"} 1`,
+		`daemon_config_rejections_total{main_key="mysql",prefix="instance"} 1`,
+		`daemon_config_rejections_total{main_key="mysql",prefix="app"} 1`,
+		`daemon_health_check_status{check="mysql",status="up"} 1`,
+		`daemon_health_check_status{check="redis",status="degraded"} 1`,
+		`daemon_health_check_status{check="redis",status="up"} 0`,
+		`daemon_health_check_duration_seconds_count{check="mysql"} 1`,
+		`daemon_build_info{build_id="abc123",channel="app",go_version=`,
+		"daemon_shutdown_duration_seconds 0.0",
+		"go_goroutines",
+		"process_start_time_seconds",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in metrics", want)
+		}
+	}
+}
@@ -45,21 +45,26 @@ func New(prefixHost, prefixClient string, logger logging.Logger) *Connector {
 		maxIdleConns: maxIdleConns,
 	}
 
+	group := conn.pxHost + "+" + conn.pxClient
 	conn.WatcherConfigFuncs = []daemon.WatcherConfigFunc{
 		daemon.WatcherConfigFunc(func() daemon.WatcherConfig {
 			return daemon.WatcherConfig{
 				Prefix:    prefixHost,
 				MainKey:   "mysql",
-				Keys:      []string{"host"},
+				Keys:      hostSchema().Names(),
+				Schema:    hostSchema(),
 				ApplyFunc: conn.connect,
+				Group:     group,
 			}
 		}),
 		daemon.WatcherConfigFunc(func() daemon.WatcherConfig {
 			return daemon.WatcherConfig{
 				Prefix:    prefixClient,
 				MainKey:   "mysql",
-				Keys:      clientConfig(),
+				Keys:      clientSchema().Names(),
+				Schema:    clientSchema(),
 				ApplyFunc: conn.connect,
+				Group:     group,
 			}
 		}),
 	}
@@ -72,7 +77,7 @@ func New(prefixHost, prefixClient string, logger logging.Logger) *Connector {
 		}
 
 		if err := conn.DB.Ping(); err != nil {
-			conn.log.Errorf("failed to ping mysql, %s", err)
+			conn.log.WithError(err).Errorf("failed to ping mysql")
 
 			return false
 		}
@@ -90,7 +95,7 @@ func New(prefixHost, prefixClient string, logger logging.Logger) *Connector {
 		}
 
 		if err := conn.DB.Close(); err != nil {
-			conn.log.Errorf("failed to close connection to mysql, %s", err)
+			conn.log.WithError(err).Errorf("failed to close connection to mysql")
 
 			return
 		}
@@ -111,12 +116,12 @@ func (db *Connector) connect(conf, last map[string]string) {
 
 	conn, err := mysql.NewConnector(db.opts)
 	if err != nil {
-		db.log.Errorf("failed to get a new connector, %s", err)
+		db.log.WithError(err).Errorf("failed to get a new connector")
 	}
 
 	if _, ok := db.DB.(*fakerDB); !ok {
 		if err := db.DB.Close(); err != nil {
-			db.log.Errorf("failed to close db, %s", err)
+			db.log.WithError(err).Errorf("failed to close db")
 		}
 
 		db.log.Debugf("mysql connection closed")
@@ -135,36 +140,134 @@ func (db *Connector) connect(conf, last map[string]string) {
 	db.log.Debugf("mysql connection open")
 }
 
-func clientConfig() []string {
-	return []string{
-		"user",
-		"password",
-		"net",
-		"db-name",
-		"collation",
-		"loc",
-		"max-allowed-packet",
-		"server-pub-key",
-		"tls-config",
-		"timeout",
-		"read-timeout",
-		"write-timeout",
-		"allow-all-files",
-		"allow-cleartext-passwords",
-		"allow-native-passwords",
-		"allow-old-passwords",
-		"check-conn-liveness",
-		"client-found-rows",
-		"columns-with-alias",
-		"interpolate-params",
-		"multi-statements",
-		"parse-time",
-		"reject-read-only",
-		"params",
-		"conn-max-idle-time",
-		"conn-max-lifetime",
-		"max-idle-conns",
-		"max-open-conns",
+func hostSchema() daemon.Schema {
+	return daemon.Schema{
+		{
+			Name:        "host",
+			Type:        daemon.TypeString,
+			Default:     "127.0.0.1:3306",
+			Description: "Address of mysql server, host:port.",
+		},
+	}
+}
+
+func clientSchema() daemon.Schema {
+	return daemon.Schema{
+		{Name: "user", Type: daemon.TypeString, Description: "Username."},
+		{Name: "password", Type: daemon.TypeString, Description: "Password.", Secret: true},
+		{Name: "net", Type: daemon.TypeString, Default: "tcp", Description: "Network type."},
+		{Name: "db-name", Type: daemon.TypeString, Description: "Database name."},
+		{
+			Name:        "collation",
+			Type:        daemon.TypeString,
+			Default:     "utf8mb4_general_ci",
+			Description: "Connection collation.",
+		},
+		{Name: "loc", Type: daemon.TypeString, Default: "UTC", Description: "Location for time.Time values."},
+		{
+			Name:        "max-allowed-packet",
+			Type:        daemon.TypeInt,
+			Default:     strconv.Itoa(maxAllowedPacket),
+			Description: "Max packet size allowed.",
+		},
+		{
+			Name:        "server-pub-key",
+			Type:        daemon.TypeString,
+			Description: "Server public key name.",
+			Secret:      true,
+		},
+		{Name: "tls-config", Type: daemon.TypeString, Description: "TLS configuration name."},
+		{Name: "timeout", Type: daemon.TypeDuration, Description: "Dial timeout."},
+		{Name: "read-timeout", Type: daemon.TypeDuration, Description: "I/O read timeout."},
+		{Name: "write-timeout", Type: daemon.TypeDuration, Description: "I/O write timeout."},
+		{
+			Name:        "allow-all-files",
+			Type:        daemon.TypeBool,
+			Default:     "false",
+			Description: "Allow all files to be used with LOAD DATA LOCAL INFILE.",
+		},
+		{
+			Name:        "allow-cleartext-passwords",
+			Type:        daemon.TypeBool,
+			Default:     "false",
+			Description: "Allows the cleartext client side plugin.",
+		},
+		{
+			Name:        "allow-native-passwords",
+			Type:        daemon.TypeBool,
+			Default:     "true",
+			Description: "Allows the native password authentication method.",
+		},
+		{
+			Name:        "allow-old-passwords",
+			Type:        daemon.TypeBool,
+			Default:     "false",
+			Description: "Allows the old insecure password method.",
+		},
+		{
+			Name:        "check-conn-liveness",
+			Type:        daemon.TypeBool,
+			Default:     "true",
+			Description: "Check connections for liveness before using them.",
+		},
+		{
+			Name:        "client-found-rows",
+			Type:        daemon.TypeBool,
+			Default:     "false",
+			Description: "Return number of matching rows instead of rows changed.",
+		},
+		{
+			Name:        "columns-with-alias",
+			Type:        daemon.TypeBool,
+			Default:     "false",
+			Description: "Prepend table alias to column names.",
+		},
+		{
+			Name:        "interpolate-params",
+			Type:        daemon.TypeBool,
+			Default:     "false",
+			Description: "Interpolate placeholders into query string.",
+		},
+		{
+			Name:        "multi-statements",
+			Type:        daemon.TypeBool,
+			Default:     "false",
+			Description: "Allow multiple statements in one query.",
+		},
+		{
+			Name:        "parse-time",
+			Type:        daemon.TypeBool,
+			Default:     "false",
+			Description: "Parse time values to time.Time.",
+		},
+		{
+			Name:        "reject-read-only",
+			Type:        daemon.TypeBool,
+			Default:     "false",
+			Description: "Reject read-only connections.",
+		},
+		{Name: "params", Type: daemon.TypeJSON, Description: "Connection parameters, JSON object."},
+		{
+			Name:        "conn-max-idle-time",
+			Type:        daemon.TypeDuration,
+			Description: "Maximum amount of time a connection may be idle.",
+		},
+		{
+			Name:        "conn-max-lifetime",
+			Type:        daemon.TypeDuration,
+			Description: "Maximum amount of time a connection may be reused.",
+		},
+		{
+			Name:        "max-idle-conns",
+			Type:        daemon.TypeInt,
+			Default:     strconv.Itoa(maxIdleConns),
+			Description: "Maximum number of connections in the idle connection pool.",
+		},
+		{
+			Name:        "max-open-conns",
+			Type:        daemon.TypeInt,
+			Description: "Maximum number of open connections, 0 is unlimited.",
+		},
 	}
 }
 
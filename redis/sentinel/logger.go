@@ -1,37 +1,31 @@
 package redis
 
 import (
-	"io"
-	"io/ioutil"
 	"log"
 	"strings"
 
 	"github.com/go-redis/redis"
-	"github.com/sirupsen/logrus"
+	"github.com/imega/daemon/logging"
 )
 
-func newLogger(l *logrus.Entry) {
-	var wlog io.Writer
-
-	wlog = &logger{log: l}
-
-	if l == nil || l.Logger.Level != logrus.DebugLevel {
-		wlog = ioutil.Discard
-	}
-
+func newLogger(l logging.Logger) {
 	redis.SetLogger(
-		log.New(wlog, "", 0),
+		log.New(&logger{log: l}, "", 0),
 	)
 }
 
 type logger struct {
-	log *logrus.Entry
+	log logging.Logger
 }
 
 func (l *logger) Write(p []byte) (n int, err error) {
+	if !logging.Enabled(l.log, logging.LevelDebug) {
+		return len(p), nil
+	}
+
 	s := strings.TrimRight(string(p), "\n")
 	s = strings.ReplaceAll(s, `"`, "")
-	l.log.Debug(s)
+	l.log.Debugf("%s", s)
 
 	return len(p), nil
 }
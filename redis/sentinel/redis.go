@@ -7,12 +7,12 @@ import (
 
 	"github.com/go-redis/redis"
 	"github.com/imega/daemon"
-	"github.com/sirupsen/logrus"
+	"github.com/imega/daemon/logging"
 )
 
 // Connector is a wrapped redis sentinel client.
 type Connector struct {
-	log logrus.FieldLogger
+	log logging.Logger
 
 	DB redis.UniversalClient
 
@@ -26,7 +26,7 @@ type Connector struct {
 }
 
 // New get a instance of redis sentinel client.
-func New(pHost, pClient string, log logrus.FieldLogger) *Connector {
+func New(pHost, pClient string, log logging.Logger) *Connector {
 	conn := &Connector{
 		opts:    &redis.FailoverOptions{},
 		pHost:   pHost + "/redis-sentinel/host",
@@ -35,55 +35,62 @@ func New(pHost, pClient string, log logrus.FieldLogger) *Connector {
 		DB:      &faker{},
 	}
 
-	if e, ok := log.(*logrus.Entry); ok {
-		newLogger(e)
+	if log == nil {
+		conn.log = logging.GetNoopLog()
 	}
 
+	newLogger(conn.log)
+
+	group := conn.pHost + "+" + conn.pClient
 	conn.WatcherConfigFuncs = []daemon.WatcherConfigFunc{
 		daemon.WatcherConfigFunc(func() daemon.WatcherConfig {
 			return daemon.WatcherConfig{
 				Prefix:    pHost,
 				MainKey:   "redis-sentinel",
-				Keys:      []string{"host"},
+				Keys:      hostSchema().Names(),
+				Schema:    hostSchema(),
 				ApplyFunc: conn.connect,
+				Group:     group,
 			}
 		}),
 		daemon.WatcherConfigFunc(func() daemon.WatcherConfig {
 			return daemon.WatcherConfig{
 				Prefix:    pClient,
 				MainKey:   "redis-sentinel",
-				Keys:      clientConfig(),
+				Keys:      clientSchema().Names(),
+				Schema:    clientSchema(),
 				ApplyFunc: conn.connect,
+				Group:     group,
 			}
 		}),
 	}
 
 	conn.ShutdownFunc = func() {
 		if conn.DB == nil {
-			conn.log.Error("failed to close connection to redis")
+			conn.log.Errorf("failed to close connection to redis")
 
 			return
 		}
 
 		if err := conn.DB.Close(); err != nil {
-			conn.log.Errorf("failed to close connection to redis, %s", err)
+			conn.log.WithError(err).Errorf("failed to close connection to redis")
 		}
 	}
 
 	conn.HealthCheckFunc = func() bool {
 		if conn.DB == nil {
-			conn.log.Error("failed to ping redis")
+			conn.log.Errorf("failed to ping redis")
 
 			return false
 		}
 
 		if _, err := conn.DB.Ping().Result(); err != nil {
-			conn.log.Errorf("failed to ping redis, %s", err)
+			conn.log.WithError(err).Errorf("failed to ping redis")
 
 			return false
 		}
 
-		conn.log.Debug("redis ping is ok")
+		conn.log.Debugf("redis ping is ok")
 
 		return true
 	}
@@ -96,24 +103,24 @@ func (c *Connector) connect(conf, last map[string]string) {
 	config := c.config(conf)
 
 	if !reset && !config {
-		c.log.Debug("redis connector has same configuration")
+		c.log.Debugf("redis connector has same configuration")
 
 		return
 	}
 
 	if _, ok := c.DB.(*faker); !ok {
 		if err := c.DB.Close(); err != nil {
-			c.log.Error(err)
+			c.log.WithError(err).Errorf("failed to close connection to redis")
 		}
 
-		c.log.Debug("redis connection closed")
+		c.log.Debugf("redis connection closed")
 
 		c.DB = &faker{}
 	}
 
 	c.DB = redis.NewFailoverClient(c.opts)
 
-	c.log.Debug("redis connection open")
+	c.log.Debugf("redis connection open")
 }
 
 func (c *Connector) config(conf map[string]string) bool {
@@ -319,22 +326,68 @@ func (c *Connector) reset(last map[string]string) bool {
 	return needUpdate
 }
 
-func clientConfig() []string {
-	return []string{
-		"master-name",
-		"password",
-		"db",
-		"max-retries",
-		"min-retry-backoff",
-		"max-retry-backoff",
-		"dial-timeout",
-		"read-timeout",
-		"write-timeout",
-		"pool-size",
-		"min-idle-conns",
-		"max-conn-age",
-		"pool-timeout",
-		"idle-timeout",
-		"idle-check-frequency",
+func hostSchema() daemon.Schema {
+	return daemon.Schema{
+		{
+			Name:        "host",
+			Type:        daemon.TypeString,
+			Description: "Addresses of sentinels, host:port, one key per sentinel host/<name>.",
+		},
+	}
+}
+
+func clientSchema() daemon.Schema {
+	return daemon.Schema{
+		{Name: "master-name", Type: daemon.TypeString, Description: "The master name."},
+		{Name: "password", Type: daemon.TypeString, Description: "Password.", Secret: true},
+		{Name: "db", Type: daemon.TypeInt, Default: "0", Description: "Database to be selected."},
+		{
+			Name:        "max-retries",
+			Type:        daemon.TypeInt,
+			Description: "Maximum number of retries before giving up.",
+		},
+		{
+			Name:        "min-retry-backoff",
+			Type:        daemon.TypeDuration,
+			Description: "Minimum backoff between each retry.",
+		},
+		{
+			Name:        "max-retry-backoff",
+			Type:        daemon.TypeDuration,
+			Description: "Maximum backoff between each retry.",
+		},
+		{
+			Name:        "dial-timeout",
+			Type:        daemon.TypeDuration,
+			Description: "Dial timeout for establishing new connections.",
+		},
+		{Name: "read-timeout", Type: daemon.TypeDuration, Description: "Timeout for socket reads."},
+		{Name: "write-timeout", Type: daemon.TypeDuration, Description: "Timeout for socket writes."},
+		{Name: "pool-size", Type: daemon.TypeInt, Description: "Maximum number of socket connections."},
+		{
+			Name:        "min-idle-conns",
+			Type:        daemon.TypeInt,
+			Description: "Minimum number of idle connections.",
+		},
+		{
+			Name:        "max-conn-age",
+			Type:        daemon.TypeDuration,
+			Description: "Connection age at which client retires the connection.",
+		},
+		{
+			Name:        "pool-timeout",
+			Type:        daemon.TypeDuration,
+			Description: "Amount of time client waits for connection if all connections are busy.",
+		},
+		{
+			Name:        "idle-timeout",
+			Type:        daemon.TypeDuration,
+			Description: "Amount of time after which client closes idle connections.",
+		},
+		{
+			Name:        "idle-check-frequency",
+			Type:        daemon.TypeDuration,
+			Description: "Frequency of idle checks made by idle connections reaper.",
+		},
 	}
 }
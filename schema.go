@@ -0,0 +1,77 @@
+// Copyright © 2022 Dmitry Stoletov <info@imega.ru>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import "strings"
+
+// Types of config values.
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeFloat    = "float"
+	TypeBool     = "bool"
+	TypeDuration = "duration"
+	TypeJSON     = "json"
+)
+
+// KeySchema describes a key of config.
+type KeySchema struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description"`
+	Secret      bool   `json:"secret,omitempty"`
+}
+
+// Schema describes the keys of WatcherConfig.
+type Schema []KeySchema
+
+// Names returns the names of keys.
+func (s Schema) Names() []string {
+	names := make([]string, 0, len(s))
+
+	for _, k := range s {
+		names = append(names, k.Name)
+	}
+
+	return names
+}
+
+// Lookup returns the schema of key.
+func (s Schema) Lookup(name string) (KeySchema, bool) {
+	for _, k := range s {
+		if k.Name == name {
+			return k, true
+		}
+	}
+
+	return KeySchema{}, false
+}
+
+// Lookup returns the schema of declared key which covers name, name is
+// relative to prefix/main-key/. A declared key also covers its subkeys,
+// host covers host/instance-1. A key declared without schema has
+// zero KeySchema.
+func (wc WatcherConfig) Lookup(name string) (KeySchema, bool) {
+	for _, k := range wc.Keys {
+		if name == k || strings.HasPrefix(name, k+"/") {
+			ks, _ := wc.Schema.Lookup(k)
+
+			return ks, true
+		}
+	}
+
+	return KeySchema{}, false
+}
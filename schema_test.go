@@ -0,0 +1,28 @@
+package daemon
+
+import "testing"
+
+func TestWatcherConfig_Lookup(t *testing.T) {
+	wConf := WatcherConfig{
+		Keys:   []string{"host", "timeout"},
+		Schema: Schema{{Name: "timeout", Type: TypeDuration}},
+	}
+
+	tests := map[string]struct {
+		typ string
+		ok  bool
+	}{
+		"host":            {ok: true},
+		"host/instance-1": {ok: true},
+		"timeout":         {typ: TypeDuration, ok: true},
+		"hostname":        {},
+		"port":            {},
+	}
+
+	for name, tt := range tests {
+		ks, ok := wConf.Lookup(name)
+		if ok != tt.ok || ks.Type != tt.typ {
+			t.Errorf("Lookup(%s) = %v, %v, want %s, %v", name, ks, ok, tt.typ, tt.ok)
+		}
+	}
+}
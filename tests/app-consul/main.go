@@ -20,7 +20,7 @@ import (
 const shutdownTimeout = 15 * time.Second
 
 func main() {
-	log := wraplogrus.New(wraplogrus.Config{
+	log := wraplogrus.NewLogger(wraplogrus.Config{
 		Channel: "ch",
 		Level:   "debug",
 	})
@@ -65,7 +65,7 @@ func main() {
 
 	d, err := daemon.New(log, cr)
 	if err != nil {
-		log.Fatal(err)
+		log.Entry().Fatal(err)
 	}
 
 	muxTest := http.NewServeMux()
@@ -85,7 +85,7 @@ func main() {
 
 	go func() {
 		if err := srvTest.ListenAndServe(); err != nil {
-			log.Fatalf("failed to serve, %s", err)
+			log.Entry().Fatalf("failed to serve, %s", err)
 		}
 	}()
 
@@ -99,17 +99,17 @@ func main() {
 
 	d.RegisterShutdownFunc(func() {
 		if err := srvTest.Shutdown(context.Background()); err != nil {
-			log.Error(err)
+			log.WithError(err).Errorf("failed to shutdown test server")
 		}
 	})
 
-	log.Info("daemon is started")
+	log.Infof("daemon is started")
 
 	if err := d.Run(shutdownTimeout); err != nil {
 		log.Errorf("failed to loop until shutdown: %s", err)
 	}
 
-	log.Info("daemon is stopped")
+	log.Infof("daemon is stopped")
 }
 
 func handler(w http.ResponseWriter, r *http.Request) {
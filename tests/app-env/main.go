@@ -18,7 +18,7 @@ import (
 const shutdownTimeout = 15 * time.Second
 
 func main() {
-	log := wraplogrus.New(wraplogrus.Config{
+	log := wraplogrus.NewLogger(wraplogrus.Config{
 		Channel: "ch",
 		Level:   "debug",
 	})
@@ -59,16 +59,16 @@ func main() {
 
 	d, err := daemon.New(log, cr)
 	if err != nil {
-		log.Fatal(err)
+		log.Entry().Fatal(err)
 	}
 
-	log.Info("daemon is started")
+	log.Infof("daemon is started")
 
 	if err := d.Run(shutdownTimeout); err != nil {
 		log.Errorf("failed to loop until shutdown: %s", err)
 	}
 
-	log.Info("daemon is stopped")
+	log.Infof("daemon is stopped")
 }
 
 func handler(w http.ResponseWriter, r *http.Request) {